@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/sirupsen/logrus"
+)
+
+// quarantineHit is an execution of a test which a quarantine entry identifies.
+// An execution is a hit of every entry which identifies the test, of which
+// only the entry that was used for the test is audited.
+type quarantineHit struct {
+	entry     *QuarantineEntry
+	classname string
+	name      string
+	status    gojunit.Status
+	used      bool
+}
+
+// QuarantineAudit lists the quarantined tests which ran, so that quarantines
+// which are stale or about to expire can be followed up on.
+type QuarantineAudit struct {
+	GeneratedAt    time.Time              `json:"generatedAt"`
+	QuarantineFile string                 `json:"quarantineFile"`
+	Tests          []QuarantineAuditEntry `json:"tests"`
+}
+
+// QuarantineAuditEntry is a quarantined test which ran, along with the entry
+// which quarantines it.
+type QuarantineAuditEntry struct {
+	Classname string `json:"classname"`
+	Name      string `json:"name"`
+	Entry     string `json:"entry"`
+	Line      int    `json:"line"`
+	Owner     string `json:"owner,omitempty"`
+	Ticket    string `json:"ticket,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Failed    int    `json:"failed"`
+	Flaky     int    `json:"flaky"`
+	Passed    int    `json:"passed"`
+	Skipped   int    `json:"skipped"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+
+	// DaysUntilEnd is the number of days left until the end date of the
+	// quarantine, which is 0 on its last day and negative once it expired.
+	DaysUntilEnd *int `json:"daysUntilEnd,omitempty"`
+	Expired      bool `json:"expired"`
+}
+
+// newQuarantineAudit returns the audit of the given hits of the quarantine file
+// at the given time, which is reported in UTC, as the dates of entries are. The
+// tests whose quarantine ends first are listed first,
+// and those without an end date last.
+func newQuarantineAudit(source string, hits []quarantineHit, now time.Time) *QuarantineAudit {
+	audit := &QuarantineAudit{
+		GeneratedAt:    now.UTC(),
+		QuarantineFile: source,
+		Tests:          make([]QuarantineAuditEntry, 0),
+	}
+
+	type auditKey struct {
+		entry           *QuarantineEntry
+		classname, name string
+	}
+	index := make(map[auditKey]int)
+	for _, hit := range hits {
+		if !hit.used {
+			continue
+		}
+		key := auditKey{hit.entry, hit.classname, hit.name}
+		i, ok := index[key]
+		if !ok {
+			i = len(audit.Tests)
+			index[key] = i
+			audit.Tests = append(audit.Tests, newQuarantineAuditEntry(hit, now))
+		}
+		switch hit.status {
+		case gojunit.StatusFailed, gojunit.StatusError:
+			audit.Tests[i].Failed++
+		case gojunit.StatusFlaky:
+			audit.Tests[i].Flaky++
+		case gojunit.StatusPassed:
+			audit.Tests[i].Passed++
+		default:
+			audit.Tests[i].Skipped++
+		}
+	}
+
+	sort.SliceStable(audit.Tests, func(i, j int) bool {
+		a, b := &audit.Tests[i], &audit.Tests[j]
+		if (a.DaysUntilEnd == nil) != (b.DaysUntilEnd == nil) {
+			return b.DaysUntilEnd == nil
+		}
+		if a.DaysUntilEnd != nil && *a.DaysUntilEnd != *b.DaysUntilEnd {
+			return *a.DaysUntilEnd < *b.DaysUntilEnd
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Classname+"."+a.Name < b.Classname+"."+b.Name
+	})
+	return audit
+}
+
+func newQuarantineAuditEntry(hit quarantineHit, now time.Time) QuarantineAuditEntry {
+	entry := hit.entry
+	audited := QuarantineAuditEntry{
+		Classname: hit.classname,
+		Name:      hit.name,
+		Entry:     entry.ID(),
+		Line:      entry.Line,
+		Owner:     entry.Owner,
+		Ticket:    entry.Ticket,
+		Reason:    entry.Reason,
+		StartDate: formatDate(entry.StartDate),
+		EndDate:   formatDate(entry.EndDate),
+		Expired:   entry.Expired(now),
+	}
+	if entry.EndDate != nil {
+		days := int(entry.EndDate.Sub(quarantineDay(now)).Hours() / 24)
+		audited.DaysUntilEnd = &days
+	}
+	return audited
+}
+
+// writeJSON writes the audit as JSON.
+func (a *QuarantineAudit) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// writeMarkdown writes the audit as a Markdown table.
+func (a *QuarantineAudit) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Quarantine audit\n\n")
+	fmt.Fprintf(&b, "%d quarantined tests from `%s` ran on %s.\n\n", len(a.Tests), a.QuarantineFile, a.GeneratedAt.Format(dateLayout))
+	if len(a.Tests) != 0 {
+		b.WriteString("| Test | Owner | Ticket | Reason | Failed | Flaky | Passed | Skipped | End date | Days left | Entry |\n")
+		b.WriteString("|---|---|---|---|---|---|---|---|---|---|---|\n")
+	}
+	for i := range a.Tests {
+		test := &a.Tests[i]
+		daysLeft := ""
+		switch {
+		case test.Expired:
+			daysLeft = "expired"
+		case test.DaysUntilEnd != nil:
+			daysLeft = fmt.Sprint(*test.DaysUntilEnd)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %d | %d | %d | %d | %s | %s | line %d |\n",
+			markdownCell(test.Classname+"."+test.Name),
+			markdownCell(test.Owner),
+			markdownCell(test.Ticket),
+			markdownCell(test.Reason),
+			test.Failed,
+			test.Flaky,
+			test.Passed,
+			test.Skipped,
+			test.EndDate,
+			daysLeft,
+			test.Line,
+		)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes the given text for a cell of a Markdown table.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// writeQuarantineAudit writes the audit to the given file, as JSON or as
+// Markdown.
+func writeQuarantineAudit(audit *QuarantineAudit, path string, markdown bool, log *logrus.Logger) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if markdown {
+		err = audit.writeMarkdown(file)
+	} else {
+		err = audit.writeJSON(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"file":  path,
+		"tests": len(audit.Tests),
+	}).Infoln("Quarantine audit written")
+	return nil
+}
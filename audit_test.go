@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditQuarantine is the quarantine file of the audit tests.
+const auditQuarantine = `
+quarantine_tests:
+  - classname: com.example.CheckoutTest
+    name: testPayment
+    owner: payments-team
+    ticket: PAY-1234
+    reason: "Sandbox | times out"
+    start_date: 2024-01-01
+    end_date: 2024-03-31
+  - classname: {glob: "com.example.*"}
+    name: "*"
+    owner: qa
+  - classname: com.example.CartTest
+    name: testAdd
+    ticket: CART-7
+    end_date: 2024-03-20
+  - classname: com.example.SearchTest
+    name: testQuery
+    end_date: 2024-04-15
+`
+
+// auditHits returns the hits of the audit tests, of the entries of the given
+// quarantine.
+func auditHits(q *Quarantine) []quarantineHit {
+	payment, wildcard, cart, search := &q.Entries[0], &q.Entries[1], &q.Entries[2], &q.Entries[3]
+	return []quarantineHit{
+		{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusFailed, true},
+		{wildcard, "com.example.LoginTest", "testLogin", gojunit.StatusPassed, true},
+		{search, "com.example.SearchTest", "testQuery", gojunit.StatusFlaky, true},
+		{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusPassed, true},
+		{cart, "com.example.CartTest", "testAdd", gojunit.StatusError, true},
+		{wildcard, "com.example.LoginTest", "testLogout", gojunit.StatusSkipped, true},
+		{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusError, true},
+		// Entries which identify a test but were not used for it are not
+		// audited.
+		{wildcard, "com.example.CheckoutTest", "testPayment", gojunit.StatusError, false},
+	}
+}
+
+func TestQuarantineAudit(t *testing.T) {
+	q, err := ParseQuarantine([]byte(auditQuarantine))
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 25, 15, 30, 0, 0, time.UTC)
+	audit := newQuarantineAudit("quarantinelist.yaml", auditHits(q), now)
+
+	for _, golden := range []struct {
+		file  string
+		write func(*bytes.Buffer) error
+	}{
+		{"testdata/quarantine-audit.json", func(b *bytes.Buffer) error { return audit.writeJSON(b) }},
+		{"testdata/quarantine-audit.md", func(b *bytes.Buffer) error { return audit.writeMarkdown(b) }},
+	} {
+		t.Run(golden.file, func(t *testing.T) {
+			expected, err := os.ReadFile(golden.file)
+			require.NoError(t, err)
+			var actual bytes.Buffer
+			require.NoError(t, golden.write(&actual))
+			assert.Equal(t, string(expected), actual.String())
+		})
+	}
+}
+
+func TestQuarantineAuditEmpty(t *testing.T) {
+	audit := newQuarantineAudit("quarantinelist.yaml", nil, time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC))
+
+	var b bytes.Buffer
+	require.NoError(t, audit.writeJSON(&b))
+	assert.Contains(t, b.String(), `"tests": []`)
+
+	b.Reset()
+	require.NoError(t, audit.writeMarkdown(&b))
+	assert.Equal(t, "# Quarantine audit\n\n0 quarantined tests from `quarantinelist.yaml` ran on 2024-03-25.\n\n", b.String())
+}
+
+// TestQuarantineAuditEndDate checks the days until the end date of an entry
+// around the end date, which is the last day of the quarantine.
+func TestQuarantineAuditEndDate(t *testing.T) {
+	q, err := ParseQuarantine([]byte("quarantine_tests:\n  - classname: A\n    name: a\n    end_date: 2024-03-31\n"))
+	require.NoError(t, err)
+	hits := []quarantineHit{{&q.Entries[0], "A", "a", gojunit.StatusFailed, true}}
+
+	tests := []struct {
+		now     time.Time
+		days    int
+		expired bool
+	}{
+		{time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC), 1, false},
+		{time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 0, false},
+		{time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC), 0, false},
+		{time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), -1, true},
+		{time.Date(2024, 4, 10, 8, 0, 0, 0, time.UTC), -10, true},
+	}
+	for _, test := range tests {
+		audit := newQuarantineAudit("quarantinelist.yaml", hits, test.now)
+		require.Len(t, audit.Tests, 1)
+		require.NotNil(t, audit.Tests[0].DaysUntilEnd)
+		assert.Equal(t, test.days, *audit.Tests[0].DaysUntilEnd, test.now)
+		assert.Equal(t, test.expired, audit.Tests[0].Expired, test.now)
+	}
+}
+
+// TestQuarantineAuditTimeZone checks that the days of the audit are those of
+// UTC, in which the dates of entries are read, rather than of the local time
+// zone, around midnight of the end date.
+func TestQuarantineAuditTimeZone(t *testing.T) {
+	q, err := ParseQuarantine([]byte("quarantine_tests:\n  - classname: A\n    name: a\n    end_date: 2024-03-31\n"))
+	require.NoError(t, err)
+	hits := []quarantineHit{{&q.Entries[0], "A", "a", gojunit.StatusFailed, true}}
+
+	tests := []struct {
+		now     time.Time
+		day     string
+		days    int
+		expired bool
+	}{
+		// The evening of the end date in New York is the next day in UTC.
+		{time.Date(2024, 3, 31, 20, 30, 0, 0, time.FixedZone("EDT", -4*60*60)), "2024-04-01", -1, true},
+		// The morning after the end date in Tokyo is still the end date in UTC.
+		{time.Date(2024, 4, 1, 8, 30, 0, 0, time.FixedZone("JST", 9*60*60)), "2024-03-31", 0, false},
+	}
+	for _, test := range tests {
+		audit := newQuarantineAudit("quarantinelist.yaml", hits, test.now)
+		require.Len(t, audit.Tests, 1)
+		assert.Equal(t, test.expired, q.Entries[0].Expired(test.now), test.now)
+		assert.Equal(t, test.expired, audit.Tests[0].Expired, test.now)
+		require.NotNil(t, audit.Tests[0].DaysUntilEnd)
+		assert.Equal(t, test.days, *audit.Tests[0].DaysUntilEnd, test.now)
+
+		var b bytes.Buffer
+		require.NoError(t, audit.writeMarkdown(&b))
+		assert.Contains(t, b.String(), "ran on "+test.day+".", test.now)
+		b.Reset()
+		require.NoError(t, audit.writeJSON(&b))
+		assert.Contains(t, b.String(), `"generatedAt": "`+test.day+"T", test.now)
+	}
+}
+
+func TestWriteQuarantineAudit(t *testing.T) {
+	q, err := ParseQuarantine([]byte(auditQuarantine))
+	require.NoError(t, err)
+	audit := newQuarantineAudit("quarantinelist.yaml", auditHits(q), time.Date(2024, 3, 25, 15, 30, 0, 0, time.UTC))
+
+	path := filepath.Join(t.TempDir(), "audit.md")
+	require.NoError(t, writeQuarantineAudit(audit, path, true, discardLogger()))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	expected, err := os.ReadFile("testdata/quarantine-audit.md")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(written))
+}
@@ -0,0 +1,311 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ArchiveSeparator separates the path of an archive from the path of an entry
+// within it, such as "results.zip!reports/TEST-a.xml".
+const ArchiveSeparator = "!"
+
+// archiveExts are the extensions of the supported zip and tar archives.
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.zst", ".tzst"}
+
+// readCloser reads a file through any number of decompressing or unpacking
+// readers, and closes all of them when it is closed.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// IsArchive returns whether the given file is a zip or tar archive, possibly
+// compressed with gzip or zstd, according to its extension.
+func IsArchive(filename string) bool {
+	lower := strings.ToLower(filename)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitArchivePath splits the given path into the path of an archive and the
+// path of an entry within it, such as "results.zip" and "reports/TEST-a.xml"
+// for "results.zip!reports/TEST-a.xml". The entry is empty for a path which
+// does not refer to an entry of an archive.
+func SplitArchivePath(name string) (archive, entry string) {
+	i := strings.Index(name, ArchiveSeparator)
+	if i < 0 || !IsArchive(name[:i]) {
+		return name, ""
+	}
+	return name[:i], name[i+len(ArchiveSeparator):]
+}
+
+// ArchiveEntries returns the paths of the regular files within the given zip or
+// tar archive, in the order in which they are stored. The archive is indexed,
+// so that its entries can be opened by OpenFile without reading it again, until
+// CloseArchives is called.
+func ArchiveEntries(archive string) ([]string, error) {
+	index, err := openArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	return index.names, nil
+}
+
+// OpenFile opens the given report file for reading. Files with a ".gz" or
+// ".zst" extension are decompressed, and a path such as
+// "results.zip!reports/TEST-a.xml" is read from the entry of the archive.
+func OpenFile(filename string) (io.ReadCloser, error) {
+	archive, entry := SplitArchivePath(filename)
+	if entry == "" {
+		return openCompressed(filename)
+	}
+	entry = path.Clean(entry)
+
+	index, err := openArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var rc *readCloser
+	if file, ok := index.files[entry]; ok {
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		rc = &readCloser{Reader: reader, closers: []io.Closer{reader}}
+	} else if section, ok := index.sections[entry]; ok {
+		rc = &readCloser{Reader: io.NewSectionReader(index.tar, section.offset, section.size)}
+	} else {
+		return nil, fmt.Errorf("%s: %w", filename, fs.ErrNotExist)
+	}
+	return rc.decompress(entry)
+}
+
+// archiveIndex is an archive whose entries can be read in any order, and at the
+// same time. A compressed tar archive is decompressed to a temporary file once,
+// so that reading each of its entries does not decompress the archive from its
+// start.
+type archiveIndex struct {
+	// names are the paths of the regular files of the archive, in the order
+	// in which they are stored.
+	names []string
+
+	// zip and files are the reader and the entries of a zip archive.
+	zip   *zip.ReadCloser
+	files map[string]*zip.File
+
+	// tar and sections are the uncompressed tar archive and the location of
+	// each of its entries within it. temp is the name of the file if it is a
+	// temporary file.
+	tar      *os.File
+	temp     string
+	sections map[string]tarSection
+}
+
+// tarSection is the location of the content of an entry of a tar archive.
+type tarSection struct {
+	offset int64
+	size   int64
+}
+
+// archives are the indexes of the archives which were opened, by their path.
+var archives = struct {
+	sync.Mutex
+	indexes map[string]*archiveIndex
+}{indexes: make(map[string]*archiveIndex)}
+
+// openArchive returns the index of the given archive, which is created when the
+// archive is first opened.
+func openArchive(archive string) (*archiveIndex, error) {
+	archives.Lock()
+	defer archives.Unlock()
+
+	if index, ok := archives.indexes[archive]; ok {
+		return index, nil
+	}
+
+	var (
+		index *archiveIndex
+		err   error
+	)
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		index, err = indexZip(archive)
+	} else {
+		index, err = indexTar(archive)
+	}
+	if err != nil {
+		return nil, err
+	}
+	archives.indexes[archive] = index
+	return index, nil
+}
+
+// CloseArchives closes the archives which were opened by ArchiveEntries and
+// OpenFile, and removes their temporary files. An archive which is opened again
+// is read again.
+func CloseArchives() error {
+	archives.Lock()
+	defer archives.Unlock()
+
+	var err error
+	for name, index := range archives.indexes {
+		if closeErr := index.close(); err == nil {
+			err = closeErr
+		}
+		delete(archives.indexes, name)
+	}
+	return err
+}
+
+func indexZip(archive string) (*archiveIndex, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &archiveIndex{zip: zr, files: make(map[string]*zip.File)}
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		name := path.Clean(file.Name)
+		index.names = append(index.names, name)
+		if _, ok := index.files[name]; !ok {
+			index.files[name] = file
+		}
+	}
+	return index, nil
+}
+
+// indexTar reads the given tar archive once, and records the location of each
+// of its entries. A compressed archive is written to a temporary file as it is
+// decompressed.
+func indexTar(archive string) (*archiveIndex, error) {
+	rc, err := openCompressed(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	index := &archiveIndex{sections: make(map[string]tarSection)}
+	src := rc.Reader
+	if file, ok := src.(*os.File); ok {
+		// The archive is not compressed, and is read as is. It is opened
+		// again, as rc closes the file.
+		if index.tar, err = os.Open(file.Name()); err != nil {
+			return nil, err
+		}
+	} else {
+		if index.tar, err = os.CreateTemp("", "archive-*.tar"); err != nil {
+			return nil, err
+		}
+		index.temp = index.tar.Name()
+		src = io.TeeReader(src, index.tar)
+	}
+
+	counter := &countingReader{reader: src}
+	tr := tar.NewReader(counter)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			_ = index.close()
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// The content of an entry follows its header, which was just read.
+		name := path.Clean(header.Name)
+		index.names = append(index.names, name)
+		if _, ok := index.sections[name]; !ok {
+			index.sections[name] = tarSection{offset: counter.count, size: header.Size}
+		}
+	}
+}
+
+// close closes the archive, and removes its temporary file.
+func (a *archiveIndex) close() error {
+	var err error
+	if a.zip != nil {
+		err = a.zip.Close()
+	}
+	if a.tar != nil {
+		err = a.tar.Close()
+	}
+	if a.temp != "" {
+		if removeErr := os.Remove(a.temp); err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
+
+// openCompressed opens the given file, decompressing it according to its
+// extension.
+func openCompressed(filename string) (*readCloser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	rc := &readCloser{Reader: file, closers: []io.Closer{file}}
+	return rc.decompress(filename)
+}
+
+// decompress wraps the reader with a gzip or zstd reader, according to the
+// extension of the given name. The reader is closed if this fails.
+func (r *readCloser) decompress(name string) (*readCloser, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".tgz":
+		gz, err := gzip.NewReader(r.Reader)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		r.Reader = gz
+		r.closers = append(r.closers, gz)
+	case ".zst", ".tzst":
+		zr, err := zstd.NewReader(r.Reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		dec := zr.IOReadCloser()
+		r.Reader = dec
+		r.closers = append(r.closers, dec)
+	}
+	return r, nil
+}
+
+// Close closes the readers in the reverse order of their creation, and returns
+// the first error.
+func (r *readCloser) Close() error {
+	var err error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if closeErr := r.closers[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
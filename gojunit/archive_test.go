@@ -0,0 +1,195 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// archiveEntries are the entries of the archives written by writeArchives.
+var archiveEntries = map[string]string{
+	"reports/TEST-surefire.xml":     "testdata/surefire.xml",
+	"reports/nested/TEST-nunit.xml": "testdata/nunit3.xml",
+}
+
+// writeArchives writes the fixtures to compressed files and archives of every
+// supported kind within the given directory.
+func writeArchives(t *testing.T, dir string) {
+	t.Helper()
+	t.Cleanup(func() {
+		assert.NoError(t, CloseArchives())
+	})
+
+	surefire, err := os.ReadFile("testdata/surefire.xml")
+	require.NoError(t, err)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err = gw.Write(surefire)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "surefire.xml.gz"), gz.Bytes(), 0o644))
+
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zst := zw.EncodeAll(surefire, nil)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "surefire.xml.zst"), zst, 0o644))
+
+	var zipped bytes.Buffer
+	zipw := zip.NewWriter(&zipped)
+	var tarred bytes.Buffer
+	tarw := tar.NewWriter(&tarred)
+	for _, name := range []string{"reports/TEST-surefire.xml", "reports/nested/TEST-nunit.xml"} {
+		data, err := os.ReadFile(archiveEntries[name])
+		require.NoError(t, err)
+
+		w, err := zipw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+
+		require.NoError(t, tarw.WriteHeader(&tar.Header{
+			Name:     "./" + name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err = tarw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipw.Close())
+	require.NoError(t, tarw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "results.zip"), zipped.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "results.tar"), tarred.Bytes(), 0o644))
+
+	gz.Reset()
+	gw = gzip.NewWriter(&gz)
+	_, err = gw.Write(tarred.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "results.tar.gz"), gz.Bytes(), 0o644))
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive string
+		entry   string
+	}{
+		{"results.zip!reports/TEST-a.xml", "results.zip", "reports/TEST-a.xml"},
+		{"build/results.tar.gz!**/*.xml", "build/results.tar.gz", "**/*.xml"},
+		{"results.TGZ!a.xml", "results.TGZ", "a.xml"},
+		{"results.zip", "results.zip", ""},
+		{"reports/wow!/TEST-a.xml", "reports/wow!/TEST-a.xml", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			archive, entry := SplitArchivePath(test.name)
+			assert.Equal(t, test.archive, archive)
+			assert.Equal(t, test.entry, entry)
+		})
+	}
+}
+
+func TestArchiveEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeArchives(t, dir)
+
+	for _, archive := range []string{"results.zip", "results.tar", "results.tar.gz"} {
+		t.Run(archive, func(t *testing.T) {
+			entries, err := ArchiveEntries(filepath.Join(dir, archive))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"reports/TEST-surefire.xml", "reports/nested/TEST-nunit.xml"}, entries)
+		})
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	writeArchives(t, dir)
+
+	files := map[string]string{
+		"surefire.xml.gz":  "testdata/surefire.xml",
+		"surefire.xml.zst": "testdata/surefire.xml",
+	}
+	for _, archive := range []string{"results.zip", "results.tar", "results.tar.gz"} {
+		for entry, fixture := range archiveEntries {
+			files[archive+ArchiveSeparator+entry] = fixture
+		}
+	}
+
+	for name, fixture := range files {
+		t.Run(name, func(t *testing.T) {
+			expected, err := os.ReadFile(fixture)
+			require.NoError(t, err)
+
+			rc, err := OpenFile(filepath.Join(dir, name))
+			require.NoError(t, err)
+			actual, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			assert.Equal(t, string(expected), string(actual))
+
+			suites, format, err := IngestFileOptions(filepath.Join(dir, name), Options{})
+			require.NoError(t, err)
+			assert.NotEqual(t, FormatAuto, format)
+			assert.NotEmpty(t, suites)
+		})
+	}
+
+	_, err := OpenFile(filepath.Join(dir, "results.zip!reports/missing.xml"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = OpenFile(filepath.Join(dir, "results.tar.gz!reports/missing.xml"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestOpenFileIndexesArchiveOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeArchives(t, dir)
+	archive := filepath.Join(dir, "results.tar.gz")
+
+	entries, err := ArchiveEntries(archive)
+	require.NoError(t, err)
+	index := archives.indexes[archive]
+	require.NotNil(t, index)
+	require.NotEmpty(t, index.temp)
+
+	// The entries are read in reverse, and at the same time, from the
+	// archive which was decompressed once.
+	var readers []io.ReadCloser
+	for i := len(entries) - 1; i >= 0; i-- {
+		rc, err := OpenFile(archive + ArchiveSeparator + entries[i])
+		require.NoError(t, err)
+		readers = append(readers, rc)
+	}
+	for i, rc := range readers {
+		expected, err := os.ReadFile(archiveEntries[entries[len(entries)-1-i]])
+		require.NoError(t, err)
+		actual, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		assert.Equal(t, string(expected), string(actual))
+	}
+	assert.Same(t, index, archives.indexes[archive])
+
+	require.NoError(t, CloseArchives())
+	assert.Empty(t, archives.indexes)
+	_, err = os.Stat(index.temp)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
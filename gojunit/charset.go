@@ -0,0 +1,313 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// xmlEncoding matches the encoding of an XML declaration.
+var xmlEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// cp1252 maps the bytes 0x80 to 0x9F of Windows-1252 to runes. The remaining
+// bytes map to the runes of the same value, as they do in ISO 8859-1, and
+// bytes which are undefined map to the C1 control characters.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// charsets maps the lowercase labels of the supported encodings to their
+// decoders. ISO 8859-1 is decoded as Windows-1252, as is common practice, as
+// the reports which declare it are usually written by Windows tools. Labels
+// which map to nil are decoded as UTF-8.
+var charsets = map[string]func(*bufio.Reader) (rune, error){
+	"utf-8":        nil,
+	"utf8":         nil,
+	"us-ascii":     nil,
+	"ascii":        nil,
+	"utf-16":       nil,
+	"utf-16le":     nil,
+	"utf-16be":     nil,
+	"utf-32":       nil,
+	"utf-32le":     nil,
+	"utf-32be":     nil,
+	"windows-1252": readCP1252,
+	"cp1252":       readCP1252,
+	"x-cp1252":     readCP1252,
+	"iso-8859-1":   readCP1252,
+	"iso8859-1":    readCP1252,
+	"iso_8859-1":   readCP1252,
+	"latin1":       readCP1252,
+	"l1":           readCP1252,
+}
+
+// decodeCharset returns a reader which yields the given report as UTF-8. The
+// encoding is sniffed from a byte order mark, from the byte pattern of an XML
+// declaration in UTF-16 or UTF-32 without one, or else from the encoding of the
+// XML declaration. A byte order mark is removed.
+func decodeCharset(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	head, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var (
+		bom   int
+		read  func(*bufio.Reader) (rune, error)
+		plain *[256]bool
+	)
+	switch {
+	case bytes.HasPrefix(head, []byte{0x00, 0x00, 0xFE, 0xFF}):
+		bom, read = 4, readUTF32(binary.BigEndian)
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE, 0x00, 0x00}):
+		bom, read = 4, readUTF32(binary.LittleEndian)
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		bom, read = 2, readUTF16(binary.BigEndian)
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		bom, read = 2, readUTF16(binary.LittleEndian)
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		bom = 3
+	case bytes.Equal(head, []byte{0x00, 0x00, 0x00, '<'}):
+		read = readUTF32(binary.BigEndian)
+	case bytes.Equal(head, []byte{'<', 0x00, 0x00, 0x00}):
+		read = readUTF32(binary.LittleEndian)
+	case bytes.Equal(head, []byte{0x00, '<', 0x00, '?'}):
+		read = readUTF16(binary.BigEndian)
+	case bytes.Equal(head, []byte{'<', 0x00, '?', 0x00}):
+		read = readUTF16(binary.LittleEndian)
+	default:
+		// The declaration is ASCII in every encoding which is not sniffed
+		// from its bytes.
+		decl, _ := buffered.Peek(256)
+		if match := xmlEncoding.FindSubmatch(decl); match != nil {
+			// The declared encodings which need decoding keep ASCII as is.
+			read, plain = charsets[strings.ToLower(string(match[1]))], &asciiBytes
+		}
+	}
+
+	if _, err := buffered.Discard(bom); err != nil {
+		return nil, err
+	}
+	if read == nil {
+		return buffered, nil
+	}
+	return &runeReader{src: buffered, read: read, plain: plain}, nil
+}
+
+// charsetReader is the CharsetReader of XML decoders. Reports are decoded to
+// UTF-8 by decodeCharset before they are parsed, so the readers of supported
+// encodings are returned as is.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	if _, ok := charsets[strings.ToLower(label)]; !ok {
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	return input, nil
+}
+
+// newXMLDecoder returns a decoder of the given UTF-8 XML report, which replaces
+// the characters that are not allowed in XML.
+func newXMLDecoder(reader io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(sanitizeXML(reader))
+	dec.CharsetReader = charsetReader
+	return dec
+}
+
+// runeReader decodes runes from a reader, and yields them as UTF-8.
+type runeReader struct {
+	src  *bufio.Reader
+	read func(*bufio.Reader) (rune, error)
+	out  []byte
+	err  error
+
+	// buf is the batch which out is what remains of, which is reused.
+	buf []byte
+
+	// plain, if set, marks the bytes which read decodes as the rune of the
+	// same value, so that runs of them are copied at once.
+	plain *[256]bool
+}
+
+var (
+	// asciiBytes are the bytes which are ASCII characters.
+	asciiBytes [256]bool
+
+	// xmlBytes are the bytes which are ASCII characters that are allowed
+	// in XML, except for the ampersand of character references.
+	xmlBytes [256]bool
+)
+
+func init() {
+	for b := 0; b < utf8.RuneSelf; b++ {
+		asciiBytes[b] = true
+		xmlBytes[b] = isXMLChar(rune(b)) && b != '&'
+	}
+}
+
+func (r *runeReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && r.err == nil {
+		r.fill()
+	}
+	if len(r.out) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// fill decodes a batch of runes.
+func (r *runeReader) fill() {
+	r.out = r.buf[:0]
+	defer func() { r.buf = r.out }()
+	for len(r.out) < 4096 {
+		if r.plain != nil {
+			buffered, _ := r.src.Peek(r.src.Buffered())
+			n := 0
+			for n < len(buffered) && r.plain[buffered[n]] {
+				n++
+			}
+			if n != 0 {
+				r.out = append(r.out, buffered[:n]...)
+				_, _ = r.src.Discard(n)
+				continue
+			}
+		}
+
+		char, err := r.read(r.src)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.out = utf8.AppendRune(r.out, char)
+	}
+}
+
+func readCP1252(src *bufio.Reader) (rune, error) {
+	b, err := src.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b >= 0x80 && b < 0xA0 {
+		return cp1252[b-0x80], nil
+	}
+	return rune(b), nil
+}
+
+func readUTF16(order binary.ByteOrder) func(*bufio.Reader) (rune, error) {
+	var unit [2]byte
+	return func(src *bufio.Reader) (rune, error) {
+		if _, err := io.ReadFull(src, unit[:]); err != nil {
+			return utf8.RuneError, truncatedRune(err)
+		}
+		char := rune(order.Uint16(unit[:]))
+		if !utf16.IsSurrogate(char) {
+			return char, nil
+		}
+
+		// A high surrogate is followed by a low surrogate, which is left in
+		// the reader if it is missing.
+		next, err := src.Peek(2)
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		pair := utf16.DecodeRune(char, rune(order.Uint16(next)))
+		if pair != utf8.RuneError {
+			_, _ = src.Discard(2)
+		}
+		return pair, nil
+	}
+}
+
+func readUTF32(order binary.ByteOrder) func(*bufio.Reader) (rune, error) {
+	var unit [4]byte
+	return func(src *bufio.Reader) (rune, error) {
+		if _, err := io.ReadFull(src, unit[:]); err != nil {
+			return utf8.RuneError, truncatedRune(err)
+		}
+		char := rune(order.Uint32(unit[:]))
+		if !utf8.ValidRune(char) {
+			return utf8.RuneError, nil
+		}
+		return char, nil
+	}
+}
+
+// truncatedRune returns the error of reading a code unit. A code unit which is
+// cut short by the end of the report is dropped.
+func truncatedRune(err error) error {
+	if err == io.ErrUnexpectedEOF {
+		return io.EOF
+	}
+	return err
+}
+
+// sanitizeXML returns a reader which replaces the characters that are not
+// allowed in XML 1.0 with U+FFFD, such as the terminal escape codes of test
+// output and invalid UTF-8. Character references to such characters, such as
+// "&#x1B;", are replaced as well.
+func sanitizeXML(reader io.Reader) io.Reader {
+	return &runeReader{src: bufio.NewReader(reader), read: readXMLChar, plain: &xmlBytes}
+}
+
+// readXMLChar reads a character which is allowed in XML.
+func readXMLChar(src *bufio.Reader) (rune, error) {
+	char, size, err := src.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case char == utf8.RuneError && size == 1:
+		return utf8.RuneError, nil
+	case char == '&':
+		return readXMLReference(src), nil
+	case isXMLChar(char):
+		return char, nil
+	default:
+		return utf8.RuneError, nil
+	}
+}
+
+// readXMLReference replaces a character reference to a character which is not
+// allowed in XML, which follows an ampersand. Other references are left as is.
+func readXMLReference(src *bufio.Reader) rune {
+	ref, _ := src.Peek(12)
+	end := bytes.IndexByte(ref, ';')
+	if end < 2 || ref[0] != '#' {
+		return '&'
+	}
+
+	digits, base := string(ref[1:end]), 10
+	if digits[0] == 'x' {
+		digits, base = digits[1:], 16
+	}
+	code, err := strconv.ParseUint(digits, base, 32)
+	if err != nil || isXMLChar(rune(code)) {
+		return '&'
+	}
+
+	_, _ = src.Discard(end + 1)
+	return utf8.RuneError
+}
+
+// isXMLChar returns whether the given character is allowed in XML 1.0.
+func isXMLChar(char rune) bool {
+	return char == '\t' || char == '\n' || char == '\r' ||
+		char >= 0x20 && char <= 0xD7FF ||
+		char >= 0xE000 && char <= 0xFFFD ||
+		char >= 0x10000 && char <= 0x10FFFF
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// charsetReport is a JUnit report with characters outside of ASCII, which is
+// encoded by the tests.
+const charsetReport = `<?xml version="1.0" encoding="%s"?>
+<testsuite name="Café">
+	<testcase classname="Naïve" name="costs 5€"><failure message="“quoted”"/></testcase>
+</testsuite>
+`
+
+// encodeUTF16 encodes the given text as UTF-16 with the given byte order.
+func encodeUTF16(text string, order binary.ByteOrder) []byte {
+	var buf bytes.Buffer
+	for _, unit := range utf16.Encode([]rune(text)) {
+		_ = binary.Write(&buf, order, unit)
+	}
+	return buf.Bytes()
+}
+
+// encodeUTF32 encodes the given text as UTF-32 with the given byte order.
+func encodeUTF32(text string, order binary.ByteOrder) []byte {
+	var buf bytes.Buffer
+	for _, char := range text {
+		_ = binary.Write(&buf, order, uint32(char))
+	}
+	return buf.Bytes()
+}
+
+func TestIngestCharsets(t *testing.T) {
+	report := func(encoding string) string {
+		return strings.Replace(charsetReport, "%s", encoding, 1)
+	}
+
+	tests := map[string][]byte{
+		"utf-8":                  []byte(report("UTF-8")),
+		"utf-8 with bom":         append([]byte("\xef\xbb\xbf"), report("UTF-8")...),
+		"utf-16le with bom":      append([]byte{0xFF, 0xFE}, encodeUTF16(report("UTF-16"), binary.LittleEndian)...),
+		"utf-16be with bom":      append([]byte{0xFE, 0xFF}, encodeUTF16(report("UTF-16"), binary.BigEndian)...),
+		"utf-16le without bom":   encodeUTF16(report("UTF-16LE"), binary.LittleEndian),
+		"utf-32le with bom":      append([]byte{0xFF, 0xFE, 0x00, 0x00}, encodeUTF32(report("UTF-32"), binary.LittleEndian)...),
+		"utf-32be with bom":      append([]byte{0x00, 0x00, 0xFE, 0xFF}, encodeUTF32(report("UTF-32"), binary.BigEndian)...),
+		"windows-1252":           []byte(strings.NewReplacer("é", "\xe9", "ï", "\xef", "€", "\x80", "“", "\x93", "”", "\x94").Replace(report("windows-1252"))),
+		"iso-8859-1 as cp1252":   []byte(strings.NewReplacer("é", "\xe9", "ï", "\xef", "€", "\x80", "“", "\x93", "”", "\x94").Replace(report("ISO-8859-1"))),
+		"undeclared utf-8 bytes": []byte(strings.Replace(report("UTF-8"), `<?xml version="1.0" encoding="UTF-8"?>`, "", 1)),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			for mode, suites := range map[string][]Suite{
+				"ingest": ingestData(t, data),
+				"stream": streamData(t, data),
+			} {
+				require.Len(t, suites, 1, mode)
+				assert.Equal(t, "Café", suites[0].Name, mode)
+				require.Len(t, suites[0].Tests, 1, mode)
+				test := suites[0].Tests[0]
+				assert.Equal(t, "Naïve", test.Classname, mode)
+				assert.Equal(t, "costs 5€", test.Name, mode)
+				assert.Equal(t, "“quoted”", test.Result.Message, mode)
+			}
+		})
+	}
+}
+
+// ingestData ingests the given report with the detected format.
+func ingestData(t *testing.T, data []byte) []Suite {
+	t.Helper()
+	suites, format, err := IngestReaderOptions(bytes.NewReader(data), Options{})
+	require.NoError(t, err)
+	require.Equal(t, FormatJUnit, format)
+	return suites
+}
+
+// streamData streams the given report of a single suite with the detected
+// format, and returns the suite along with its tests.
+func streamData(t *testing.T, data []byte) []Suite {
+	t.Helper()
+	var streamed Suite
+	_, err := StreamReader(bytes.NewReader(data), Options{}, StreamHandler{
+		Test: func(_ SuitePath, test *Test) error {
+			streamed.Tests = append(streamed.Tests, *test)
+			return nil
+		},
+		Suite: func(_ SuitePath, suite *Suite) error {
+			streamed.Name = suite.Name
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	return []Suite{streamed}
+}
+
+func TestIngestUnsupportedCharset(t *testing.T) {
+	_, _, err := IngestReaderOptions(strings.NewReader(strings.Replace(charsetReport, "%s", "EBCDIC-US", 1)), Options{})
+	assert.ErrorContains(t, err, `unsupported charset "EBCDIC-US"`)
+}
+
+func TestIngestUTF16JSON(t *testing.T) {
+	data, err := os.ReadFile("testdata/jest.json")
+	require.NoError(t, err)
+
+	expected, err := IngestReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	encoded := append([]byte{0xFF, 0xFE}, encodeUTF16(string(data), binary.LittleEndian)...)
+	actual, format, err := IngestReaderOptions(bytes.NewReader(encoded), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatJest, format)
+	assert.Equal(t, expected, actual)
+}
+
+func TestSanitizeXML(t *testing.T) {
+	input := "<testsuite name=\"a\">" +
+		"<testcase name=\"colors\"><failure message=\"\x1b[31mred&#x1B;[0m\">bell\x07 &#7; &amp; &#233; &#x1F600;</failure>" +
+		"<system-out>invalid \xff utf-8</system-out></testcase>" +
+		"</testsuite>"
+
+	suites, err := IngestReader(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+	require.Len(t, suites[0].Tests, 1)
+
+	test := suites[0].Tests[0]
+	assert.Equal(t, "�[31mred�[0m", test.Result.Message)
+	assert.Equal(t, "bell� � & é 😀", test.Result.Desc)
+	assert.Equal(t, "invalid � utf-8", test.SystemOut)
+}
@@ -0,0 +1,300 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ctrfSuiteSeparator separates the names of nested suites in the suite of a
+// CTRF test.
+const ctrfSuiteSeparator = " > "
+
+type (
+	// CTRF is a Common Test Report Format (https://ctrf.io) JSON document.
+	CTRF struct {
+		ReportFormat string      `json:"reportFormat"`
+		SpecVersion  string      `json:"specVersion"`
+		Timestamp    string      `json:"timestamp,omitempty"`
+		GeneratedBy  string      `json:"generatedBy,omitempty"`
+		Results      CTRFResults `json:"results"`
+	}
+
+	// CTRFResults contains the tests of a CTRF document.
+	CTRFResults struct {
+		Tool    CTRFTool    `json:"tool"`
+		Summary CTRFSummary `json:"summary"`
+		Tests   []CTRFTest  `json:"tests"`
+	}
+
+	// CTRFTool describes the tool which ran the tests.
+	CTRFTool struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+
+	// CTRFSummary contains the number of tests of each status, and the start
+	// and stop times of the run in milliseconds since the epoch.
+	CTRFSummary struct {
+		Tests   int   `json:"tests"`
+		Passed  int   `json:"passed"`
+		Failed  int   `json:"failed"`
+		Pending int   `json:"pending"`
+		Skipped int   `json:"skipped"`
+		Other   int   `json:"other"`
+		Start   int64 `json:"start"`
+		Stop    int64 `json:"stop"`
+	}
+
+	// CTRFTest is a single test of a CTRF document.
+	CTRFTest struct {
+		Name      string                 `json:"name"`
+		Status    string                 `json:"status"`
+		Duration  float64                `json:"duration"`
+		Suite     string                 `json:"suite,omitempty"`
+		Message   string                 `json:"message,omitempty"`
+		Trace     string                 `json:"trace,omitempty"`
+		RawStatus string                 `json:"rawStatus,omitempty"`
+		Tags      []string               `json:"tags,omitempty"`
+		Type      string                 `json:"type,omitempty"`
+		FilePath  string                 `json:"filePath,omitempty"`
+		Retries   int                    `json:"retries,omitempty"`
+		Flaky     bool                   `json:"flaky,omitempty"`
+		Stdout    []string               `json:"stdout,omitempty"`
+		Stderr    []string               `json:"stderr,omitempty"`
+		Browser   string                 `json:"browser,omitempty"`
+		Extra     map[string]interface{} `json:"extra,omitempty"`
+	}
+)
+
+// IngestCTRF will parse the given CTRF document and return a slice with a test
+// suite for every distinct suite of its tests, in the order in which they first
+// appear. Tests without a suite are grouped in a suite named after the tool.
+func IngestCTRF(reader io.Reader) ([]Suite, error) {
+	var doc CTRF
+	if err := decodeJSON(reader, &doc); err != nil {
+		return nil, err
+	}
+
+	var (
+		suites []Suite
+		byName = make(map[string]int)
+	)
+	for i := range doc.Results.Tests {
+		test := &doc.Results.Tests[i]
+
+		name := test.Suite
+		if name == "" {
+			name = doc.Results.Tool.Name
+		}
+
+		index, ok := byName[name]
+		if !ok {
+			index = len(suites)
+			byName[name] = index
+			suites = append(suites, Suite{
+				Name:       name,
+				Properties: map[string]string{"tool": doc.Results.Tool.Name},
+			})
+		}
+
+		suites[index].Tests = append(suites[index].Tests, ingestCTRFTest(test))
+	}
+
+	for i := range suites {
+		suites[i].Aggregate()
+	}
+
+	return suites, nil
+}
+
+func ingestCTRFTest(test *CTRFTest) Test {
+	result := Test{
+		Name:       test.Name,
+		Classname:  test.Suite,
+		Filename:   test.FilePath,
+		DurationMs: int64(test.Duration),
+		Result: Result{
+			Status:  ctrfStatus(test.Status, test.RawStatus),
+			Message: test.Message,
+			Desc:    test.Trace,
+		},
+		Properties: make(map[string]string),
+		SystemOut:  strings.Join(test.Stdout, "\n"),
+		SystemErr:  strings.Join(test.Stderr, "\n"),
+	}
+
+	// The classname and error type are not part of the format, and are only
+	// present in documents written by this package.
+	if classname, ok := test.Extra["classname"].(string); ok && classname != "" {
+		result.Classname = classname
+	}
+	if errorType, ok := test.Extra["errorType"].(string); ok {
+		result.Result.Type = errorType
+	}
+
+	for name, value := range map[string]string{
+		"rawStatus": test.RawStatus,
+		"type":      test.Type,
+		"browser":   test.Browser,
+		"tags":      strings.Join(test.Tags, ","),
+	} {
+		if value != "" {
+			result.Properties[name] = value
+		}
+	}
+	if test.Retries != 0 {
+		result.Properties["retries"] = strconv.Itoa(test.Retries)
+	}
+	if test.Flaky {
+		result.Properties["flaky"] = "true"
+	}
+
+	return result
+}
+
+// ctrfStatus maps the status of a CTRF test to a status. Tests with the "other"
+// status, such as interrupted tests, are reported as errors, as are failed
+// tests whose raw status is an error, as written by Add.
+func ctrfStatus(status, raw string) Status {
+	switch status {
+	case "passed":
+		return StatusPassed
+	case "failed":
+		if raw == StatusError {
+			return StatusError
+		}
+		return StatusFailed
+	case "skipped", "pending":
+		return StatusSkipped
+	default:
+		return StatusError
+	}
+}
+
+// NewCTRF returns an empty CTRF document for the given tool, to which suites
+// can be added.
+func NewCTRF(tool string) *CTRF {
+	return &CTRF{
+		ReportFormat: "CTRF",
+		SpecVersion:  "0.0.0",
+		GeneratedBy:  tool,
+		Results: CTRFResults{
+			Tool:  CTRFTool{Name: tool},
+			Tests: make([]CTRFTest, 0),
+		},
+	}
+}
+
+// Add adds the tests of the given suites, including those of nested suites, to
+// the document. The suite of each test is the path of suite names leading to
+// it, separated by " > ".
+func (c *CTRF) Add(suites []Suite) {
+	WalkTests(suites, c.AddTest)
+}
+
+// AddTest adds a single test of the suite at the given path to the document.
+func (c *CTRF) AddTest(path SuitePath, test *Test) {
+	result := newCTRFTest(test, strings.Join(path, ctrfSuiteSeparator))
+	c.Results.Tests = append(c.Results.Tests, result)
+
+	summary := &c.Results.Summary
+	summary.Tests++
+	switch result.Status {
+	case "passed":
+		summary.Passed++
+	case "failed":
+		summary.Failed++
+	case "skipped":
+		summary.Skipped++
+	default:
+		summary.Other++
+	}
+}
+
+// Merge adds the tests of another document to the document.
+func (c *CTRF) Merge(other *CTRF) {
+	c.Results.Tests = append(c.Results.Tests, other.Results.Tests...)
+
+	summary := &c.Results.Summary
+	summary.Tests += other.Results.Summary.Tests
+	summary.Passed += other.Results.Summary.Passed
+	summary.Failed += other.Results.Summary.Failed
+	summary.Skipped += other.Results.Summary.Skipped
+	summary.Pending += other.Results.Summary.Pending
+	summary.Other += other.Results.Summary.Other
+}
+
+func newCTRFTest(test *Test, suite string) CTRFTest {
+	result := CTRFTest{
+		Name:     test.Name,
+		Duration: float64(test.DurationMs),
+		Suite:    suite,
+		Message:  test.Result.Message,
+		Trace:    test.Result.Desc,
+		FilePath: test.Filename,
+		Flaky:    test.DerivedStatus() == StatusFlaky,
+		Extra:    make(map[string]interface{}),
+	}
+
+	switch test.Result.Status {
+	case StatusPassed, StatusFailed, StatusSkipped:
+		result.Status = string(test.Result.Status)
+	case StatusError:
+		result.Status = "failed"
+		result.RawStatus = StatusError
+	default:
+		result.Status = "other"
+		result.RawStatus = string(test.Result.Status)
+	}
+
+	if retries, err := strconv.Atoi(test.Properties["retries"]); err == nil {
+		result.Retries = retries
+	}
+	if len(test.Attempts) != 0 {
+		result.Retries = len(test.Attempts)
+	}
+	if test.SystemOut != "" {
+		result.Stdout = strings.Split(test.SystemOut, "\n")
+	}
+	if test.SystemErr != "" {
+		result.Stderr = strings.Split(test.SystemErr, "\n")
+	}
+	if test.Classname != "" {
+		result.Extra["classname"] = test.Classname
+	}
+	if test.Result.Type != "" {
+		result.Extra["errorType"] = test.Result.Type
+	}
+
+	return result
+}
+
+// Write writes the document as indented JSON. The run is assumed to have
+// stopped when the document is written, and to have started the sum of the
+// test durations earlier, as the original start times are not known.
+func (c *CTRF) Write(writer io.Writer) error {
+	return c.write(writer, time.Now())
+}
+
+func (c *CTRF) write(writer io.Writer, stop time.Time) error {
+	var duration float64
+	for i := range c.Results.Tests {
+		duration += c.Results.Tests[i].Duration
+	}
+
+	c.Timestamp = stop.UTC().Format(time.RFC3339)
+	c.Results.Summary.Stop = stop.UnixMilli()
+	c.Results.Summary.Start = c.Results.Summary.Stop - int64(duration)
+
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(c)
+}
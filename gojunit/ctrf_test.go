@@ -0,0 +1,116 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestCTRF(t *testing.T) {
+	file, err := os.Open("testdata/ctrf.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestCTRF(file)
+	require.NoError(t, err)
+	require.Len(t, suites, 3)
+
+	dashboard := suites[0]
+	assert.Equal(t, "dashboard.spec.ts > Dashboard", dashboard.Name)
+	assert.Equal(t, map[string]string{"tool": "playwright"}, dashboard.Properties)
+	assert.Equal(t, Totals{Tests: 2, Passed: 2, DurationMs: 2100}, dashboard.Totals)
+
+	passed := dashboard.Tests[0]
+	assert.Equal(t, "shows the dashboard", passed.Name)
+	assert.Equal(t, "dashboard.spec.ts > Dashboard", passed.Classname)
+	assert.Equal(t, "tests/dashboard.spec.ts", passed.Filename)
+	assert.Equal(t, "navigated to /dashboard\nrendered 3 widgets", passed.SystemOut)
+	assert.Equal(t, map[string]string{"browser": "chromium", "tags": "@smoke"}, passed.Properties)
+
+	flaky := dashboard.Tests[1]
+	assert.Equal(t, "true", flaky.Properties["flaky"])
+	assert.Equal(t, "1", flaky.Properties["retries"])
+
+	login := suites[1]
+	assert.Equal(t, Totals{Tests: 2, Skipped: 1, Failed: 1, DurationMs: 1500}, login.Totals)
+
+	failed := login.Tests[0]
+	assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+	assert.Equal(t, "Error: expect(locator).toBeVisible() failed", failed.Result.Message)
+	assert.Contains(t, failed.Result.Desc, "at tests/login.spec.ts:21:5")
+	assert.Equal(t, "console.error: 401 Unauthorized", failed.SystemErr)
+
+	other := suites[2]
+	assert.Equal(t, "playwright", other.Name)
+	require.Len(t, other.Tests, 1)
+	assert.Equal(t, Status(StatusError), other.Tests[0].Result.Status)
+	assert.Equal(t, "interrupted", other.Tests[0].Properties["rawStatus"])
+}
+
+func TestWriteCTRF(t *testing.T) {
+	suites, err := IngestFile("testdata/nunit3.xml")
+	require.NoError(t, err)
+
+	report := NewCTRF("parse-test-reports")
+	report.Add(suites)
+
+	var buf bytes.Buffer
+	stop := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, report.write(&buf, stop))
+
+	var doc CTRF
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "CTRF", doc.ReportFormat)
+	assert.Equal(t, "2024-05-01T12:00:00Z", doc.Timestamp)
+	assert.Equal(t, "parse-test-reports", doc.Results.Tool.Name)
+	assert.Equal(t, suites[0].Totals.Tests, doc.Results.Summary.Tests)
+	assert.Equal(t, suites[0].Totals.Passed, doc.Results.Summary.Passed)
+	assert.Equal(t, suites[0].Totals.Failed+suites[0].Totals.Error, doc.Results.Summary.Failed)
+	assert.Equal(t, stop.UnixMilli(), doc.Results.Summary.Stop)
+	assert.Equal(t, stop.UnixMilli()-suites[0].Totals.DurationMs, doc.Results.Summary.Start)
+
+	// Writing and ingesting a document preserves the tests.
+	roundTrip, err := IngestCTRF(&buf)
+	require.NoError(t, err)
+
+	var before, after []Test
+	WalkTests(suites, func(_ SuitePath, test *Test) {
+		before = append(before, *test)
+	})
+	for _, suite := range roundTrip {
+		after = append(after, suite.Tests...)
+	}
+	require.Len(t, after, len(before))
+	for i := range before {
+		assert.Equal(t, before[i].Name, after[i].Name)
+		assert.Equal(t, before[i].Classname, after[i].Classname)
+		assert.Equal(t, before[i].Result.Status, after[i].Result.Status)
+		assert.Equal(t, before[i].Result.Type, after[i].Result.Type)
+	}
+}
+
+func TestMergeCTRF(t *testing.T) {
+	suites, err := IngestFile("testdata/nunit3.xml")
+	require.NoError(t, err)
+
+	whole := NewCTRF("parse-test-reports")
+	whole.Add(suites)
+
+	merged := NewCTRF("parse-test-reports")
+	for i := range suites {
+		part := NewCTRF("parse-test-reports")
+		part.Add(suites[i : i+1])
+		merged.Merge(part)
+	}
+
+	assert.Equal(t, whole.Results, merged.Results)
+}
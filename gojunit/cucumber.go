@@ -0,0 +1,323 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// cucumberFeature is a feature of a Cucumber JSON report, as written by
+	// Cucumber (JVM, JS and Ruby) and Behave.
+	cucumberFeature struct {
+		URI      string            `json:"uri"`
+		Location string            `json:"location"`
+		ID       string            `json:"id"`
+		Keyword  string            `json:"keyword"`
+		Name     string            `json:"name"`
+		Tags     []cucumberTag     `json:"tags"`
+		Elements []cucumberElement `json:"elements"`
+	}
+
+	// cucumberElement is a scenario, an example of a scenario outline, or a
+	// background of a feature.
+	cucumberElement struct {
+		ID      string         `json:"id"`
+		Keyword string         `json:"keyword"`
+		Name    string         `json:"name"`
+		Type    string         `json:"type"`
+		Line    int            `json:"line"`
+		Tags    []cucumberTag  `json:"tags"`
+		Before  []cucumberStep `json:"before"`
+		Steps   []cucumberStep `json:"steps"`
+		After   []cucumberStep `json:"after"`
+	}
+
+	// cucumberStep is a step, or a hook which runs before or after a step or
+	// scenario.
+	cucumberStep struct {
+		Keyword string         `json:"keyword"`
+		Name    string         `json:"name"`
+		Hidden  bool           `json:"hidden"`
+		Match   cucumberMatch  `json:"match"`
+		Result  cucumberResult `json:"result"`
+		Before  []cucumberStep `json:"before"`
+		After   []cucumberStep `json:"after"`
+		Output  []string       `json:"output"`
+	}
+
+	// cucumberTag is a tag, which Behave writes as a plain string.
+	cucumberTag struct {
+		Name string `json:"name"`
+	}
+
+	cucumberMatch struct {
+		Location string `json:"location"`
+	}
+
+	cucumberResult struct {
+		Status       string       `json:"status"`
+		Duration     json.Number  `json:"duration"`
+		ErrorMessage cucumberText `json:"error_message"`
+	}
+
+	// cucumberText is a string which Behave writes as an array of lines.
+	cucumberText string
+)
+
+// UnmarshalJSON accepts either a tag object or the name of the tag.
+func (t *cucumberTag) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Name); err == nil {
+		return nil
+	}
+
+	var tag struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+	t.Name = tag.Name
+	return nil
+}
+
+// UnmarshalJSON accepts either a string or an array of lines.
+func (t *cucumberText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = cucumberText(strings.Join(lines, "\n"))
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*t = cucumberText(text)
+	return nil
+}
+
+// IngestCucumber will parse the given Cucumber JSON report and return a slice
+// with a test suite for every feature, and a test for every scenario. The
+// status of a scenario is derived from the status of its steps and hooks, and
+// scenarios with undefined or pending steps are given the pending status,
+// which defaults to StatusFailed.
+func IngestCucumber(reader io.Reader, pending Status) ([]Suite, error) {
+	var features []cucumberFeature
+	if err := decodeJSON(reader, &features); err != nil {
+		return nil, err
+	}
+
+	if pending == "" {
+		pending = StatusFailed
+	}
+
+	suites := make([]Suite, 0, len(features))
+	for i := range features {
+		suites = append(suites, ingestCucumberFeature(&features[i], pending))
+	}
+
+	return suites, nil
+}
+
+func ingestCucumberFeature(feature *cucumberFeature, pending Status) Suite {
+	// Behave reports the location of a feature rather than its URI.
+	behave := feature.URI == "" && feature.Location != ""
+
+	filename := feature.URI
+	if behave {
+		// Behave reports the location of a feature as "path:line".
+		filename = feature.Location
+		if i := strings.LastIndex(filename, ":"); i > 0 {
+			filename = filename[:i]
+		}
+	}
+
+	suite := Suite{
+		Name:       feature.Name,
+		Package:    filename,
+		Properties: make(map[string]string),
+	}
+	if feature.ID != "" {
+		suite.Properties["id"] = feature.ID
+	}
+	if tags := cucumberTags(feature.Tags); tags != "" {
+		suite.Properties["tags"] = tags
+	}
+
+	// The examples of a scenario outline share the name of the outline, so
+	// scenarios whose name is not unique within the feature are numbered.
+	names := make(map[string]int)
+	for i := range feature.Elements {
+		if feature.Elements[i].Type != "background" {
+			names[feature.Elements[i].Name]++
+		}
+	}
+
+	var (
+		background []cucumberStep
+		examples   = make(map[string]int)
+	)
+	for i := range feature.Elements {
+		element := &feature.Elements[i]
+
+		// Cucumber repeats the background before every scenario it applies
+		// to, and its steps are part of the scenario.
+		if element.Type == "background" {
+			background = element.Steps
+			continue
+		}
+
+		test := ingestCucumberScenario(element, background, pending, behave)
+		test.Classname = suite.Name
+		test.Filename = filename
+		if names[element.Name] > 1 {
+			examples[element.Name]++
+			test.Name = fmt.Sprintf("%s (example %d)", test.Name, examples[element.Name])
+		}
+
+		suite.Tests = append(suite.Tests, test)
+		background = nil
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+func ingestCucumberScenario(element *cucumberElement, background []cucumberStep, pending Status, behave bool) Test {
+	test := Test{
+		Name:       element.Name,
+		Result:     Result{Status: StatusPassed},
+		Properties: make(map[string]string),
+	}
+	if element.ID != "" {
+		test.Properties["id"] = element.ID
+	}
+	if element.Line != 0 {
+		test.Properties["line"] = strconv.Itoa(element.Line)
+	}
+	if tags := cucumberTags(element.Tags); tags != "" {
+		test.Properties["tags"] = tags
+	}
+
+	var (
+		steps    = cucumberSteps(element, background)
+		duration time.Duration
+		failed   *cucumberStep
+		undone   *cucumberStep
+		skipped  bool
+		output   []string
+	)
+	for _, step := range steps {
+		duration += cucumberDuration(step.Result.Duration, behave)
+		output = append(output, step.Output...)
+
+		switch step.Result.Status {
+		case "passed":
+		case "failed", "ambiguous":
+			if failed == nil {
+				failed = step
+			}
+		case "undefined", "pending":
+			if undone == nil {
+				undone = step
+			}
+		default:
+			// Skipped steps, and steps which Behave did not run.
+			skipped = true
+		}
+	}
+
+	test.DurationMs = duration.Milliseconds()
+	test.SystemOut = strings.Join(output, "\n")
+
+	switch {
+	case failed != nil:
+		test.Result.Status = StatusFailed
+		// A failing hook prevents the scenario from running rather than
+		// violating its expectations.
+		if isCucumberHook(failed) {
+			test.Result.Status = StatusError
+		}
+		test.Result.Message = cucumberStepText(failed)
+		test.Result.Desc = string(failed.Result.ErrorMessage)
+	case undone != nil:
+		test.Result.Status = pending
+		test.Result.Message = fmt.Sprintf("%s step: %s", undone.Result.Status, cucumberStepText(undone))
+		test.Result.Desc = string(undone.Result.ErrorMessage)
+	case skipped:
+		test.Result.Status = StatusSkipped
+	}
+
+	return test
+}
+
+// cucumberSteps returns the hooks and steps of a scenario in the order in
+// which they ran, including the steps of its background.
+func cucumberSteps(element *cucumberElement, background []cucumberStep) []*cucumberStep {
+	var steps []*cucumberStep
+	add := func(list []cucumberStep) {
+		for i := range list {
+			steps = append(steps, &list[i])
+		}
+	}
+
+	add(element.Before)
+	for _, list := range [][]cucumberStep{background, element.Steps} {
+		for i := range list {
+			add(list[i].Before)
+			steps = append(steps, &list[i])
+			add(list[i].After)
+		}
+	}
+	add(element.After)
+
+	return steps
+}
+
+// isCucumberHook returns whether the given step is a hook. Cucumber JS reports
+// hooks as hidden steps, while other implementations report them separately
+// and without a keyword.
+func isCucumberHook(step *cucumberStep) bool {
+	keyword := strings.TrimSpace(step.Keyword)
+	return step.Hidden || keyword == "" || keyword == "Before" || keyword == "After"
+}
+
+// cucumberStepText returns the text of a step, such as "Given a user", or the
+// location of a hook.
+func cucumberStepText(step *cucumberStep) string {
+	if isCucumberHook(step) && step.Name == "" {
+		return strings.TrimSpace("hook " + step.Match.Location)
+	}
+	return strings.TrimSpace(strings.TrimSpace(step.Keyword) + " " + step.Name)
+}
+
+// cucumberDuration converts the duration of a step to a time.Duration. Cucumber
+// reports durations in nanoseconds, while Behave reports them in seconds, even
+// when they are whole numbers.
+func cucumberDuration(number json.Number, behave bool) time.Duration {
+	value, err := number.Float64()
+	if err != nil {
+		return 0
+	}
+	if behave {
+		return time.Duration(value * float64(time.Second))
+	}
+	return time.Duration(value)
+}
+
+// cucumberTags returns the names of the given tags, joined with a comma.
+func cucumberTags(tags []cucumberTag) string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestCucumber(t *testing.T) {
+	file, err := os.Open("testdata/cucumber.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestCucumber(file, "")
+	require.NoError(t, err)
+	require.Len(t, suites, 2)
+
+	login := suites[0]
+	assert.Equal(t, "Login", login.Name)
+	assert.Equal(t, "features/login.feature", login.Package)
+	assert.Equal(t, map[string]string{"id": "login", "tags": "@auth"}, login.Properties)
+	assert.Equal(t, Totals{Tests: 4, Passed: 2, Failed: 2, DurationMs: 1071}, login.Totals)
+
+	passed := login.Tests[0]
+	assert.Equal(t, "Successful login", passed.Name)
+	assert.Equal(t, "Login", passed.Classname)
+	assert.Equal(t, "features/login.feature", passed.Filename)
+	assert.Equal(t, Status(StatusPassed), passed.Result.Status)
+	assert.Equal(t, int64(1000), passed.DurationMs)
+	assert.Equal(t, "logged in as admin", passed.SystemOut)
+	assert.Equal(t, map[string]string{"id": "login;successful-login", "line": "8", "tags": "@auth,@smoke"}, passed.Properties)
+
+	failed := login.Tests[1]
+	assert.Equal(t, "Wrong password", failed.Name)
+	assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+	assert.Equal(t, "Then I see an error", failed.Result.Message)
+	assert.Contains(t, failed.Result.Desc, "java.lang.AssertionError: expected error banner")
+	assert.Equal(t, int64(51), failed.DurationMs)
+
+	assert.Equal(t, "Locked accounts (example 1)", login.Tests[2].Name)
+	assert.Equal(t, Status(StatusPassed), login.Tests[2].Result.Status)
+
+	undefined := login.Tests[3]
+	assert.Equal(t, "Locked accounts (example 2)", undefined.Name)
+	assert.Equal(t, Status(StatusFailed), undefined.Result.Status)
+	assert.Equal(t, "undefined step: Then my account is unlocked by support", undefined.Result.Message)
+
+	checkout := suites[1]
+	require.Len(t, checkout.Tests, 3)
+	assert.Equal(t, Totals{Tests: 3, Skipped: 1, Failed: 1, Error: 1, DurationMs: 8}, checkout.Totals)
+
+	hook := checkout.Tests[0]
+	assert.Equal(t, Status(StatusError), hook.Result.Status)
+	assert.Equal(t, "hook steps.Hooks.seedDatabase()", hook.Result.Message)
+	assert.Equal(t, "java.sql.SQLException: connection refused", hook.Result.Desc)
+
+	pending := checkout.Tests[1]
+	assert.Equal(t, Status(StatusFailed), pending.Result.Status)
+	assert.Equal(t, "pending step: When I pay by invoice", pending.Result.Message)
+	assert.Equal(t, "io.cucumber.java.PendingException: TODO: implement me", pending.Result.Desc)
+
+	assert.Equal(t, Status(StatusSkipped), checkout.Tests[2].Result.Status)
+}
+
+func TestIngestCucumberPendingSkipped(t *testing.T) {
+	suites, _, err := IngestFileOptions("testdata/cucumber.json", Options{PendingStatus: StatusSkipped})
+	require.NoError(t, err)
+	require.Len(t, suites, 2)
+
+	assert.Equal(t, Status(StatusSkipped), suites[0].Tests[3].Result.Status)
+	assert.Equal(t, Status(StatusSkipped), suites[1].Tests[1].Result.Status)
+	assert.Equal(t, Totals{Tests: 3, Skipped: 2, Error: 1, DurationMs: 8}, suites[1].Totals)
+}
+
+func TestIngestCucumberBehave(t *testing.T) {
+	file, err := os.Open("testdata/behave.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestCucumber(file, StatusFailed)
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+	require.Len(t, suites[0].Tests, 1)
+
+	test := suites[0].Tests[0]
+	assert.Equal(t, "Search by title", test.Name)
+	assert.Equal(t, "features/search.feature", test.Filename)
+	assert.Equal(t, "slow", test.Properties["tags"])
+	assert.Equal(t, int64(262), test.DurationMs)
+	assert.Equal(t, Status(StatusFailed), test.Result.Status)
+	assert.Equal(t, "Then I find 3 books", test.Result.Message)
+	assert.Equal(t, "Assertion Failed: expected 3 books, found 2\nCaptured logging:\nINFO:search:query=title", test.Result.Desc)
+}
+
+func TestIngestCucumberDurationUnits(t *testing.T) {
+	tests := map[string]struct {
+		report   string
+		expected int64
+	}{
+		"cucumber nanoseconds": {
+			report:   `[{"uri": "a.feature", "name": "A", "elements": [{"name": "s", "type": "scenario", "steps": [{"result": {"status": "passed", "duration": 2000000}}]}]}]`,
+			expected: 2,
+		},
+		"behave whole seconds": {
+			report:   `[{"location": "a.feature:1", "name": "A", "elements": [{"name": "s", "type": "scenario", "steps": [{"result": {"status": "passed", "duration": 2}}]}]}]`,
+			expected: 2000,
+		},
+		"behave fractional seconds": {
+			report:   `[{"location": "a.feature:1", "name": "A", "elements": [{"name": "s", "type": "scenario", "steps": [{"result": {"status": "passed", "duration": 0.5}}]}]}]`,
+			expected: 500,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			suites, err := IngestCucumber(strings.NewReader(test.report), StatusFailed)
+			require.NoError(t, err)
+			require.Len(t, suites, 1)
+			require.Len(t, suites[0].Tests, 1)
+			assert.Equal(t, test.expected, suites[0].Tests[0].DurationMs)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format identifies the format of a test report.
+type Format string
+
+const (
+	// FormatAuto detects the format from the contents of the report.
+	FormatAuto Format = ""
+
+	// FormatJUnit is a JUnit XML report.
+	FormatJUnit Format = "junit"
+
+	// FormatNUnit is an NUnit 2 or NUnit 3 XML report.
+	FormatNUnit Format = "nunit"
+
+	// FormatTRX is a Visual Studio (MSTest) TRX report.
+	FormatTRX Format = "trx"
+
+	// FormatXUnit is an xUnit.net v2 XML report.
+	FormatXUnit Format = "xunit"
+
+	// FormatTestNG is a TestNG "testng-results.xml" report.
+	FormatTestNG Format = "testng"
+
+	// FormatTAP is a Test Anything Protocol (version 13 or 14) report.
+	FormatTAP Format = "tap"
+
+	// FormatGoTestJSON is a "go test -json" event stream.
+	FormatGoTestJSON Format = "gotest-json"
+
+	// FormatCucumber is a Cucumber JSON report, as written by Cucumber and
+	// Behave.
+	FormatCucumber Format = "cucumber"
+
+	// FormatCTRF is a Common Test Report Format JSON document.
+	FormatCTRF Format = "ctrf"
+
+	// FormatJest is the output of "jest --json".
+	FormatJest Format = "jest"
+
+	// FormatMocha is the output of the Mocha "json" reporter or of Mochawesome.
+	FormatMocha Format = "mocha"
+
+	// FormatPlaywright is the output of the Playwright "json" reporter.
+	FormatPlaywright Format = "playwright"
+)
+
+// Options configures the ingestion of a report.
+type Options struct {
+	// Format is the format of the report, or FormatAuto to detect it.
+	Format Format
+
+	// PendingStatus is the status of Cucumber scenarios with undefined or
+	// pending steps. Defaults to StatusFailed.
+	PendingStatus Status
+
+	// MaxOutputBytes caps the captured stdout and stderr of each test and
+	// suite when a report is streamed. Zero means no limit.
+	MaxOutputBytes int
+
+	// Recover salvages the tests of a JUnit report which was truncated before
+	// all of its tags were closed, instead of rejecting the whole report.
+	Recover bool
+
+	// Filename is the name of the report, which is used to name the error test
+	// of a truncated report. It is set by IngestFileOptions and StreamFile.
+	Filename string
+}
+
+// ingester ingests a report of a single format.
+type ingester func(io.Reader, Options) ([]Suite, error)
+
+// sniffLen is the number of bytes inspected to detect the format of a report.
+const sniffLen = 8192
+
+// ingesters maps each supported format to the function which ingests it.
+var ingesters = map[Format]ingester{
+	FormatJUnit:      ingestJUnit,
+	FormatNUnit:      xmlIngester(FormatNUnit),
+	FormatTRX:        xmlIngester(FormatTRX),
+	FormatXUnit:      xmlIngester(FormatXUnit),
+	FormatTestNG:     xmlIngester(FormatTestNG),
+	FormatTAP:        withoutOptions(IngestTAP),
+	FormatGoTestJSON: withoutOptions(IngestGoTestJSON),
+	FormatCTRF:       withoutOptions(IngestCTRF),
+	FormatJest:       withoutOptions(IngestJest),
+	FormatMocha:      withoutOptions(IngestMocha),
+	FormatPlaywright: withoutOptions(IngestPlaywright),
+	FormatCucumber: func(reader io.Reader, opts Options) ([]Suite, error) {
+		return IngestCucumber(reader, opts.PendingStatus)
+	},
+}
+
+// withoutOptions adapts an ingester which does not take any options.
+func withoutOptions(ingest func(io.Reader) ([]Suite, error)) ingester {
+	return func(reader io.Reader, _ Options) ([]Suite, error) {
+		return ingest(reader)
+	}
+}
+
+// ParseFormat returns the format with the given name. An empty name, or
+// "auto", returns FormatAuto.
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "auto" {
+		return FormatAuto, nil
+	}
+	if _, ok := ingesters[Format(name)]; !ok {
+		return FormatAuto, fmt.Errorf("unsupported report format %q", name)
+	}
+	return Format(name), nil
+}
+
+// DetectFormat inspects the first bytes of a report and returns its format, or
+// FormatAuto if the format could not be detected. Blank reports are detected
+// as JUnit, which yields no suites, and XML documents with an unrecognised
+// root tag are detected as JUnit, as "testsuite" tags may be nested anywhere.
+func DetectFormat(data []byte) Format {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case len(trimmed) == 0:
+		return FormatJUnit
+	case trimmed[0] == '<':
+		return detectXMLFormat(trimmed)
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		if format := detectJSONFormat(trimmed); format != FormatAuto {
+			return format
+		}
+	}
+
+	return detectLineFormat(trimmed)
+}
+
+// detectXMLFormat returns the format identified by the first tag of the given
+// XML document which is recognised by findSuites.
+func detectXMLFormat(data []byte) Format {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	// Only tag names are of interest, so the declared encoding is ignored.
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return FormatJUnit
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if root, ok := xmlRoots[start.Name.Local]; ok {
+				return root.format
+			}
+		}
+	}
+}
+
+// detectJSONFormat returns the format of a JSON document, based on the keys of
+// its first object.
+func detectJSONFormat(data []byte) Format {
+	array, keys := jsonKeys(data)
+
+	switch {
+	case keys["Action"]:
+		return FormatGoTestJSON
+	case array && (keys["elements"] || keys["uri"] && keys["keyword"]):
+		return FormatCucumber
+	case array:
+		// The remaining formats are objects.
+	case keys["testResults"] || keys["numTotalTests"]:
+		return FormatJest
+	case keys["config"]:
+		return FormatPlaywright
+	case keys["stats"]:
+		// Mochawesome reports have results as well, so stats are checked
+		// before the results of CTRF.
+		return FormatMocha
+	case keys["results"]:
+		return FormatCTRF
+	}
+	return FormatAuto
+}
+
+// detectLineFormat returns the format of a line based report, such as TAP, or a
+// "go test -json" stream which is interleaved with build output.
+func detectLineFormat(data []byte) Format {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "TAP version"), tapPlan.MatchString(line), tapTestPoint.MatchString(line):
+			return FormatTAP
+		case strings.HasPrefix(line, "{"):
+			if _, keys := jsonKeys([]byte(line)); keys["Action"] {
+				return FormatGoTestJSON
+			}
+		}
+	}
+	return FormatAuto
+}
+
+// jsonKeys returns whether the given, possibly truncated, JSON document is an
+// array, and the keys of its first object.
+func jsonKeys(data []byte) (bool, map[string]bool) {
+	var (
+		dec  = json.NewDecoder(bytes.NewReader(data))
+		keys = make(map[string]bool)
+	)
+
+	token, err := dec.Token()
+	if err != nil {
+		return false, keys
+	}
+
+	array := token == json.Delim('[')
+	if array {
+		if token, err = dec.Token(); err != nil {
+			return array, keys
+		}
+	}
+	if token != json.Delim('{') {
+		return array, keys
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, ok := token.(string)
+		if !ok {
+			break
+		}
+		keys[key] = true
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			break
+		}
+	}
+
+	return array, keys
+}
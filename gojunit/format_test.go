@@ -0,0 +1,171 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		title    string
+		input    string
+		expected Format
+	}{
+		{
+			title:    "empty input",
+			expected: FormatJUnit,
+		},
+		{
+			title:    "junit testsuites",
+			input:    `<?xml version="1.0"?><testsuites><testsuite name="a"/></testsuites>`,
+			expected: FormatJUnit,
+		},
+		{
+			title:    "unknown xml root",
+			input:    `<report><suites/></report>`,
+			expected: FormatJUnit,
+		},
+		{
+			title:    "xml with byte order mark and comment",
+			input:    "\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"windows-1252\"?>\n<!-- generated --><test-run/>",
+			expected: FormatNUnit,
+		},
+		{
+			title:    "trx with namespace",
+			input:    `<TestRun xmlns="http://microsoft.com/schemas/VisualStudio/TeamTest/2010">`,
+			expected: FormatTRX,
+		},
+		{
+			title:    "truncated xunit",
+			input:    `<assemblies><assembly name="tests.dll" total="3"><collection name="Test collection for`,
+			expected: FormatXUnit,
+		},
+		{
+			title:    "go test json",
+			input:    `{"Time":"2024-03-01T10:00:00Z","Action":"start","Package":"example.com/calc"}`,
+			expected: FormatGoTestJSON,
+		},
+		{
+			title:    "go test json after build output",
+			input:    "# example.com/broken\n./broken.go:3:1: syntax error\n{\"Action\":\"start\",\"Package\":\"example.com/broken\"}\n",
+			expected: FormatGoTestJSON,
+		},
+		{
+			title:    "cucumber json",
+			input:    `[{"uri":"features/login.feature","id":"login","keyword":"Feature","name":"Login","elements":[`,
+			expected: FormatCucumber,
+		},
+		{
+			title:    "behave json",
+			input:    `[{"keyword": "Feature", "name": "Search", "location": "features/search.feature:1", "elements": []}]`,
+			expected: FormatCucumber,
+		},
+		{
+			title:    "ctrf",
+			input:    `{"reportFormat": "CTRF", "specVersion": "0.0.0", "results": {"tool": {"name": "jest"}, "tests": [`,
+			expected: FormatCTRF,
+		},
+		{
+			title:    "jest",
+			input:    `{"numFailedTestSuites":0,"numFailedTests":0,"numPassedTestSuites":1,"numPassedTests":2,"numTotalTests":2,"testResults":[`,
+			expected: FormatJest,
+		},
+		{
+			title:    "mocha",
+			input:    `{"stats": {"suites": 1, "tests": 2}, "tests": [`,
+			expected: FormatMocha,
+		},
+		{
+			title:    "mochawesome",
+			input:    `{"stats": {"suites": 1, "tests": 2}, "results": [{"uuid": "1", "suites": [`,
+			expected: FormatMocha,
+		},
+		{
+			title:    "playwright with large config",
+			input:    `{"config": {"projects": [{"name": "chromium"`,
+			expected: FormatPlaywright,
+		},
+		{
+			title:    "tap without version",
+			input:    "1..2\nok 1\nok 2\n",
+			expected: FormatTAP,
+		},
+		{
+			title:    "unknown json",
+			input:    `{"hello": "world"}`,
+			expected: FormatAuto,
+		},
+		{
+			title:    "plaintext",
+			input:    "This is some data that does not look like a report.",
+			expected: FormatAuto,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("#%d - %s", index+1, test.title)
+
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, DetectFormat([]byte(test.input)))
+		})
+	}
+}
+
+func TestIngestFileFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		format   Format
+		expected Format
+		suites   int
+		err      string
+	}{
+		{filename: "testdata/surefire.xml", expected: FormatJUnit, suites: 1},
+		{filename: "testdata/nunit3.xml", expected: FormatNUnit, suites: 1},
+		{filename: "testdata/nunit2.xml", expected: FormatNUnit, suites: 1},
+		{filename: "testdata/trx.xml", expected: FormatTRX, suites: 2},
+		{filename: "testdata/xunit.xml", expected: FormatXUnit, suites: 1},
+		{filename: "testdata/testng.xml", expected: FormatTestNG, suites: 1},
+		{filename: "testdata/tap14.tap", expected: FormatTAP, suites: 1},
+		{filename: "testdata/go-test.json", expected: FormatGoTestJSON, suites: 4},
+		{filename: "testdata/cucumber.json", expected: FormatCucumber, suites: 2},
+		{filename: "testdata/behave.json", expected: FormatCucumber, suites: 1},
+		{filename: "testdata/ctrf.json", expected: FormatCTRF, suites: 3},
+		{filename: "testdata/jest.json", expected: FormatJest, suites: 2},
+		{filename: "testdata/mocha.json", expected: FormatMocha, suites: 2},
+		{filename: "testdata/mochawesome.json", expected: FormatMocha, suites: 2},
+		{filename: "testdata/playwright.json", expected: FormatPlaywright, suites: 2},
+		{filename: "testdata/nunit3.xml", format: FormatJUnit, expected: FormatJUnit, suites: 0},
+		{filename: "testdata/tap14.tap", format: "bogus", expected: "bogus", err: `unsupported report format "bogus"`},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("#%d - %s", index+1, test.filename)
+
+		t.Run(name, func(t *testing.T) {
+			suites, format, err := IngestFileFormat(test.filename, test.format)
+			checkError(t, test.err, err)
+			assert.Equal(t, test.expected, format)
+			assert.Len(t, suites, test.suites)
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" NUnit ")
+	require.NoError(t, err)
+	assert.Equal(t, FormatNUnit, format)
+
+	format, err = ParseFormat("auto")
+	require.NoError(t, err)
+	assert.Equal(t, FormatAuto, format)
+
+	_, err = ParseFormat("surefire")
+	assert.EqualError(t, err, `unsupported report format "surefire"`)
+}
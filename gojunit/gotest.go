@@ -0,0 +1,194 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// goTestEvent is a single event of the "go test -json" (test2json) stream.
+type goTestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	ImportPath  string
+	FailedBuild string
+}
+
+// goTestPackage accumulates the events of a single package.
+type goTestPackage struct {
+	suite       Suite
+	tests       map[string]int
+	output      strings.Builder
+	failed      bool
+	failedBuild string
+}
+
+// IngestGoTestJSON will parse the given "go test -json" event stream and return
+// a slice with a test suite for every package. Tests and subtests are reported
+// as individual tests, and a package which fails without a failing test, such
+// as a build failure or a panic during initialization, is reported with a
+// synthesized error test.
+func IngestGoTestJSON(reader io.Reader) ([]Suite, error) {
+	var (
+		packages []*goTestPackage
+		byName   = make(map[string]*goTestPackage)
+		builds   = make(map[string]*strings.Builder)
+		buffered = bufio.NewReader(reader)
+		offset   int64
+	)
+
+	for {
+		line, err := buffered.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		start := offset
+		offset += int64(len(line))
+
+		// Build errors and other output that is not part of the event stream
+		// are often interleaved when stderr is redirected, and are ignored.
+		if trimmed := bytes.TrimSpace(line); len(trimmed) != 0 && trimmed[0] == '{' {
+			var event goTestEvent
+			if jsonErr := json.Unmarshal(trimmed, &event); jsonErr != nil {
+				start += int64(bytes.IndexByte(line, '{'))
+				return nil, jsonSyntaxError(jsonErr, start, offset)
+			}
+
+			switch {
+			case event.Action == "build-output":
+				if builds[event.ImportPath] == nil {
+					builds[event.ImportPath] = &strings.Builder{}
+				}
+				builds[event.ImportPath].WriteString(event.Output)
+			case event.Package != "":
+				pkg := byName[event.Package]
+				if pkg == nil {
+					pkg = &goTestPackage{
+						suite: Suite{Name: event.Package},
+						tests: make(map[string]int),
+					}
+					byName[event.Package] = pkg
+					packages = append(packages, pkg)
+				}
+				pkg.handle(&event)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	suites := make([]Suite, 0, len(packages))
+	for _, pkg := range packages {
+		var build string
+		if output := builds[pkg.failedBuild]; output != nil {
+			build = output.String()
+		}
+		suites = append(suites, pkg.finish(build))
+	}
+
+	return suites, nil
+}
+
+func (p *goTestPackage) handle(event *goTestEvent) {
+	if event.Test == "" {
+		switch event.Action {
+		case "output":
+			p.output.WriteString(event.Output)
+		case "fail":
+			p.failed = true
+			p.failedBuild = event.FailedBuild
+		}
+		return
+	}
+
+	index, ok := p.tests[event.Test]
+	if !ok {
+		index = len(p.suite.Tests)
+		p.tests[event.Test] = index
+		p.suite.Tests = append(p.suite.Tests, Test{
+			Name:      event.Test,
+			Classname: event.Package,
+		})
+	}
+
+	test := &p.suite.Tests[index]
+	switch event.Action {
+	case "output":
+		test.SystemOut += event.Output
+	case "pass":
+		test.Result.Status = StatusPassed
+		test.DurationMs = goTestElapsed(event.Elapsed)
+	case "skip":
+		test.Result.Status = StatusSkipped
+		test.DurationMs = goTestElapsed(event.Elapsed)
+	case "fail":
+		test.Result.Status = StatusFailed
+		test.Result.Desc = test.SystemOut
+		test.DurationMs = goTestElapsed(event.Elapsed)
+	}
+}
+
+// finish returns the suite of the package once all events have been handled,
+// given the build output of the package if it failed to build.
+func (p *goTestPackage) finish(build string) Suite {
+	suite := p.suite
+	suite.SystemOut = p.output.String()
+
+	failedTests := false
+	for i := range suite.Tests {
+		test := &suite.Tests[i]
+		switch test.Result.Status {
+		case StatusFailed:
+			failedTests = true
+		case "":
+			// The test never finished, which happens when the test binary
+			// panics or is killed by a timeout.
+			test.Result.Status = StatusError
+			test.Result.Message = "test did not complete"
+			test.Result.Desc = test.SystemOut
+			failedTests = true
+		}
+	}
+
+	if p.failed && !failedTests {
+		test := Test{
+			Name:      "[setup failed]",
+			Classname: suite.Name,
+			Result: Result{
+				Status:  StatusError,
+				Message: "package failed without a failing test",
+				Desc:    suite.SystemOut,
+			},
+		}
+		// Prior to Go 1.24 build failures are only reported in the output.
+		if p.failedBuild != "" || strings.Contains(suite.SystemOut, "[build failed]") {
+			test.Name = "[build failed]"
+			test.Result.Message = "package failed to build"
+			if build != "" {
+				test.Result.Desc = build
+			}
+		}
+		suite.Tests = append(suite.Tests, test)
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+// goTestElapsed converts an elapsed time in seconds to milliseconds.
+func goTestElapsed(seconds float64) int64 {
+	return time.Duration(seconds * float64(time.Second)).Milliseconds()
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestGoTestJSON(t *testing.T) {
+	file, err := os.Open("testdata/go-test.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestGoTestJSON(file)
+	require.NoError(t, err)
+	require.Len(t, suites, 4)
+
+	calc := suites[0]
+	assert.Equal(t, "example.com/calc", calc.Name)
+	assert.Len(t, calc.Tests, 5)
+	assert.Equal(t, Totals{Tests: 5, Passed: 2, Skipped: 1, Failed: 2, DurationMs: 512}, calc.Totals)
+	assert.Contains(t, calc.SystemOut, "FAIL\texample.com/calc")
+
+	passed := calc.Tests[0]
+	assert.Equal(t, "TestAdd", passed.Name)
+	assert.Equal(t, "example.com/calc", passed.Classname)
+	assert.Equal(t, Status(StatusPassed), passed.Result.Status)
+	assert.Equal(t, int64(12), passed.DurationMs)
+	assert.Equal(t, "=== RUN   TestAdd\n--- PASS: TestAdd (0.01s)\n", passed.SystemOut)
+
+	subtest := calc.Tests[2]
+	assert.Equal(t, "TestDivide/by_zero", subtest.Name)
+	assert.Equal(t, Status(StatusFailed), subtest.Result.Status)
+	assert.Equal(t, int64(250), subtest.DurationMs)
+	assert.Contains(t, subtest.Result.Desc, "calc_test.go:27: expected error, got nil")
+
+	assert.Equal(t, "TestDivide", calc.Tests[1].Name)
+	assert.Equal(t, Status(StatusFailed), calc.Tests[1].Result.Status)
+	assert.Equal(t, "TestDivide/case_1", calc.Tests[3].Name)
+	assert.Equal(t, Status(StatusSkipped), calc.Tests[4].Result.Status)
+
+	config := suites[1]
+	require.Len(t, config.Tests, 1)
+	assert.Equal(t, "[setup failed]", config.Tests[0].Name)
+	assert.Equal(t, Status(StatusError), config.Tests[0].Result.Status)
+	assert.Contains(t, config.Tests[0].Result.Desc, "panic: missing CONFIG_PATH")
+
+	broken := suites[2]
+	require.Len(t, broken.Tests, 1)
+	assert.Equal(t, "[build failed]", broken.Tests[0].Name)
+	assert.Equal(t, Status(StatusError), broken.Tests[0].Result.Status)
+	assert.Contains(t, broken.Tests[0].Result.Desc, "undefined: missing")
+
+	assert.Equal(t, "example.com/util", suites[3].Name)
+	assert.Len(t, suites[3].Tests, 0)
+}
+
+func TestIngestGoTestJSONIncomplete(t *testing.T) {
+	input := `{"Action":"run","Package":"example.com/calc","Test":"TestHang"}
+{"Action":"output","Package":"example.com/calc","Test":"TestHang","Output":"panic: test timed out after 10m0s\n"}
+{"Action":"fail","Package":"example.com/calc","Elapsed":600}
+`
+	suites, err := IngestGoTestJSON(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+	require.Len(t, suites[0].Tests, 1)
+
+	test := suites[0].Tests[0]
+	assert.Equal(t, "TestHang", test.Name)
+	assert.Equal(t, Status(StatusError), test.Result.Status)
+	assert.Equal(t, "test did not complete", test.Result.Message)
+	assert.Contains(t, test.Result.Desc, "test timed out")
+}
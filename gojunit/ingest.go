@@ -14,16 +14,36 @@ import (
 	"time"
 )
 
+// xmlRoot describes a tag which identifies a report format, and how the tag is
+// ingested.
+type xmlRoot struct {
+	format Format
+	ingest func(root xmlNode, suites chan Suite)
+}
+
+// xmlRoots maps the tags which identify each XML report format: JUnit
+// "testsuite" tags, NUnit 3 "test-run" and NUnit 2 "test-results" tags, Visual
+// Studio TRX "TestRun" tags, xUnit.net v2 "assembly" tags and TestNG
+// "testng-results" tags.
+var xmlRoots = map[string]xmlRoot{
+	"testsuite":      {FormatJUnit, func(root xmlNode, suites chan Suite) { suites <- ingestSuite(root) }},
+	"test-run":       {FormatNUnit, findNUnitSuites},
+	"test-results":   {FormatNUnit, findNUnitSuites},
+	"TestRun":        {FormatTRX, findTRXSuites},
+	"assembly":       {FormatXUnit, func(root xmlNode, suites chan Suite) { suites <- ingestXUnitAssembly(root) }},
+	"testng-results": {FormatTestNG, findTestNGSuites},
+}
+
 // findSuites performs a depth-first search through the XML document, and
-// attempts to ingest any "testsuite" tags that are encountered.
-func findSuites(nodes []xmlNode, suites chan Suite) {
+// attempts to ingest any tags that identify a report of the given format, or
+// of any format for FormatAuto.
+func findSuites(nodes []xmlNode, format Format, suites chan Suite) {
 	for _, node := range nodes {
-		switch node.XMLName.Local {
-		case "testsuite":
-			suites <- ingestSuite(node)
-		default:
-			findSuites(node.Nodes, suites)
+		if root, ok := xmlRoots[node.XMLName.Local]; ok && (format == FormatAuto || format == root.format) {
+			root.ingest(node, suites)
+			continue
 		}
+		findSuites(node.Nodes, format, suites)
 	}
 }
 
@@ -80,6 +100,7 @@ func ingestTestcase(root xmlNode) Test { //nolint:gocritic
 		Properties: root.Attrs,
 	}
 
+	var failures []Result
 	for _, node := range root.Nodes {
 		switch node.XMLName.Local {
 		case "skipped":
@@ -91,11 +112,15 @@ func ingestTestcase(root xmlNode) Test { //nolint:gocritic
 			test.Result.Message = node.Attr("message")
 			test.Result.Type = node.Attr("type")
 			test.Result.Desc = string(node.Content)
+			failures = append(failures, test.Result)
 		case "error":
 			test.Result.Status = StatusError
 			test.Result.Message = node.Attr("message")
 			test.Result.Type = node.Attr("type")
 			test.Result.Desc = string(node.Content)
+			failures = append(failures, test.Result)
+		case "rerunFailure", "rerunError", "flakyFailure", "flakyError":
+			test.Attempts = append(test.Attempts, ingestRerun(node))
 		case "system-out":
 			test.SystemOut = string(node.Content)
 		case "system-err":
@@ -103,9 +128,49 @@ func ingestTestcase(root xmlNode) Test { //nolint:gocritic
 		}
 	}
 
+	if len(failures) > 1 {
+		test.Failures = failures
+	}
+
 	return test
 }
 
+// rerunStatuses maps the tags of Surefire for the failed runs of a test which
+// was rerun to their status. The reruns of a test which failed every run are
+// reported with "rerunFailure" and "rerunError" tags, and the runs of a flaky
+// test which passed when rerun with "flakyFailure" and "flakyError" tags.
+var rerunStatuses = map[string]Status{
+	"rerunFailure": StatusFailed,
+	"rerunError":   StatusError,
+	"flakyFailure": StatusFailed,
+	"flakyError":   StatusError,
+}
+
+// ingestRerun ingests a failed run of a test which was rerun as an attempt.
+func ingestRerun(root xmlNode) Attempt { //nolint:gocritic
+	attempt := Attempt{
+		DurationMs: duration(root.Attr("time")).Milliseconds(),
+		Result: Result{
+			Status:  rerunStatuses[root.XMLName.Local],
+			Message: root.Attr("message"),
+			Type:    root.Attr("type"),
+		},
+	}
+
+	for _, node := range root.Nodes {
+		switch node.XMLName.Local {
+		case "stackTrace":
+			attempt.Result.Desc = string(node.Content)
+		case "system-out":
+			attempt.SystemOut = string(node.Content)
+		case "system-err":
+			attempt.SystemErr = string(node.Content)
+		}
+	}
+
+	return attempt
+}
+
 func duration(t string) time.Duration {
 	// Remove commas for larger durations
 	t = strings.ReplaceAll(t, ",", "")
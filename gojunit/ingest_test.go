@@ -223,6 +223,290 @@ func TestExamplesInTheWild(t *testing.T) { //nolint:funlen
 				assert.Equal(t, expectedTotals, actualTotals)
 			},
 		},
+		{
+			title:    "nunit3 example",
+			filename: "testdata/nunit3.xml",
+			origin:   "https://docs.nunit.org/articles/nunit/technical-notes/usage/Test-Result-XML-Format.html",
+			check: func(t *testing.T, suites []Suite) {
+				assert.Len(t, suites, 1)
+				assert.Equal(t, "Calculator.Tests.dll", suites[0].Name)
+				assert.Equal(t, "4242", suites[0].Properties["_PID"])
+				assert.Len(t, suites[0].Suites, 1)
+
+				fixtures := suites[0].Suites[0].Suites
+				assert.Len(t, fixtures, 2)
+				assert.Equal(t, "Calculator.CalculatorTests", fixtures[0].Package)
+				assert.Len(t, fixtures[0].Tests, 3)
+				assert.Len(t, fixtures[1].Tests, 2)
+
+				passed := fixtures[0].Tests[0]
+				assert.Equal(t, "Add", passed.Name)
+				assert.Equal(t, "Calculator.CalculatorTests", passed.Classname)
+				assert.Equal(t, Status(StatusPassed), passed.Result.Status)
+				assert.Equal(t, int64(12), passed.DurationMs)
+				assert.Equal(t, "Fast,Math", passed.Properties["Category"])
+				assert.Equal(t, "payments", passed.Properties["Owner"])
+				assert.Equal(t, "adding 1 and 2\n", passed.SystemOut)
+
+				failed := fixtures[0].Tests[1]
+				assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+				assert.Equal(t, "  Expected: 2\n  But was:  3\n", failed.Result.Message)
+				assert.Contains(t, failed.Result.Desc, "CalculatorTests.cs:line 27")
+
+				skipped := fixtures[0].Tests[2]
+				assert.Equal(t, Status(StatusSkipped), skipped.Result.Status)
+				assert.Equal(t, "Not implemented yet", skipped.Result.Message)
+
+				errored := fixtures[1].Tests[1]
+				assert.Equal(t, `Parse(null)`, errored.Name)
+				assert.Equal(t, "Calculator.ParserTests", errored.Classname)
+				assert.Equal(t, Status(StatusError), errored.Result.Status)
+				assert.Equal(t, "System.ArgumentNullException", errored.Result.Type)
+				assert.Equal(t, "parsing null\n", errored.SystemOut)
+
+				expectedTotals := Totals{
+					Tests:      5,
+					Passed:     2,
+					Skipped:    1,
+					Failed:     1,
+					Error:      1,
+					DurationMs: 422,
+				}
+				assert.Equal(t, expectedTotals, suites[0].Totals)
+			},
+		},
+		{
+			title:    "nunit2 example",
+			filename: "testdata/nunit2.xml",
+			check: func(t *testing.T, suites []Suite) {
+				assert.Len(t, suites, 1)
+				assert.Equal(t, `C:\build\Calculator.Tests.dll`, suites[0].Name)
+
+				fixture := suites[0].Suites[0].Suites[0]
+				assert.Equal(t, "CalculatorTests", fixture.Name)
+				assert.Equal(t, "Unit", fixture.Properties["Category"])
+				assert.Len(t, fixture.Tests, 4)
+
+				var testcase = Test{
+					Name:       "Divide(4.5,1.5)",
+					Classname:  "Calculator.CalculatorTests",
+					DurationMs: 104,
+					Result: Result{
+						Status:  StatusFailed,
+						Message: "  Expected: 3\n  But was:  2\n",
+						Desc:    "at Calculator.CalculatorTests.Divide(Double a, Double b) in c:\\build\\CalculatorTests.cs:line 27\n",
+					},
+					Properties: map[string]string{
+						"name":     "Calculator.CalculatorTests.Divide(4.5,1.5)",
+						"executed": "True",
+						"result":   "Failure",
+						"success":  "False",
+						"time":     "0.104",
+						"asserts":  "1",
+					},
+				}
+				assert.Equal(t, testcase, fixture.Tests[1])
+
+				assert.Equal(t, "Add", fixture.Tests[0].Name)
+				assert.Equal(t, "Fast,Math", fixture.Tests[0].Properties["Category"])
+				assert.Equal(t, "payments", fixture.Tests[0].Properties["Owner"])
+				assert.Equal(t, Status(StatusSkipped), fixture.Tests[2].Result.Status)
+				assert.Equal(t, Status(StatusError), fixture.Tests[3].Result.Status)
+				assert.Equal(t, "System.NullReferenceException", fixture.Tests[3].Result.Type)
+			},
+		},
+		{
+			title:    "trx example",
+			filename: "testdata/trx.xml",
+			origin:   "https://learn.microsoft.com/en-us/dotnet/core/testing/unit-testing-platform-extensions-test-reports",
+			check: func(t *testing.T, suites []Suite) {
+				assert.Len(t, suites, 2)
+				assert.Equal(t, "Calculator.Tests.CalculatorTests", suites[0].Name)
+				assert.Len(t, suites[0].Tests, 3)
+				assert.Equal(t, "Calculator.Tests.ParserTests", suites[1].Name)
+				assert.Len(t, suites[1].Tests, 2)
+
+				passed := suites[0].Tests[0]
+				assert.Equal(t, "Add", passed.Name)
+				assert.Equal(t, Status(StatusPassed), passed.Result.Status)
+				assert.Equal(t, int64(12), passed.DurationMs)
+				assert.Equal(t, "adding 1 and 2", passed.SystemOut)
+				assert.Equal(t, "Fast,Math", passed.Properties["TestCategory"])
+
+				failed := suites[0].Tests[1]
+				assert.Equal(t, "Divide", failed.Name)
+				assert.Equal(t, "Calculator.Tests.CalculatorTests", failed.Classname)
+				assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+				assert.Equal(t, int64(1250), failed.DurationMs)
+				assert.Equal(t, "Assert.AreEqual failed. Expected:<2>. Actual:<3>. ", failed.Result.Message)
+				assert.Contains(t, failed.Result.Desc, "CalculatorTests.cs:line 27")
+				assert.Equal(t, "dividing 6 by 3", failed.SystemOut)
+				assert.Equal(t, "warning: slow division", failed.SystemErr)
+
+				skipped := suites[0].Tests[2]
+				assert.Equal(t, "Calculator.Tests.CalculatorTests", skipped.Classname)
+				assert.Equal(t, Status(StatusSkipped), skipped.Result.Status)
+
+				assert.Equal(t, "Parse (1.5)", suites[1].Tests[0].Name)
+				assert.Equal(t, Status(StatusPassed), suites[1].Tests[0].Result.Status)
+				assert.Equal(t, "Parse (null)", suites[1].Tests[1].Name)
+				assert.Equal(t, Status(StatusError), suites[1].Tests[1].Result.Status)
+			},
+		},
+		{
+			title:    "xunit example",
+			filename: "testdata/xunit.xml",
+			origin:   "https://xunit.net/docs/format-xml-v2",
+			check: func(t *testing.T, suites []Suite) {
+				assert.Len(t, suites, 1)
+				assert.Equal(t, "/src/Calculator.Tests/bin/Debug/net6.0/Calculator.Tests.dll", suites[0].Name)
+				assert.Len(t, suites[0].Suites, 2)
+
+				assert.Len(t, suites[0].Tests, 1)
+				cleanup := suites[0].Tests[0]
+				assert.Equal(t, "Calculator.Tests.DatabaseTests", cleanup.Name)
+				assert.Equal(t, Status(StatusError), cleanup.Result.Status)
+				assert.Equal(t, "System.InvalidOperationException", cleanup.Result.Type)
+
+				collection := suites[0].Suites[0]
+				assert.Equal(t, "Test collection for Calculator.Tests.CalculatorTests", collection.Name)
+				assert.Len(t, collection.Tests, 3)
+
+				passed := collection.Tests[0]
+				assert.Equal(t, "Add", passed.Name)
+				assert.Equal(t, "Calculator.Tests.CalculatorTests", passed.Classname)
+				assert.Equal(t, int64(12), passed.DurationMs)
+				assert.Equal(t, "Fast,Math", passed.Properties["Category"])
+				assert.Equal(t, "payments", passed.Properties["Owner"])
+				assert.Equal(t, "adding 1 and 2\n", passed.SystemOut)
+
+				failed := collection.Tests[1]
+				assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+				assert.Equal(t, "Xunit.Sdk.EqualException", failed.Result.Type)
+				assert.Equal(t, "Assert.Equal() Failure\nExpected: 2\nActual:   3", failed.Result.Message)
+				assert.Contains(t, failed.Result.Desc, "CalculatorTests.cs:line 27")
+
+				skipped := collection.Tests[2]
+				assert.Equal(t, Status(StatusSkipped), skipped.Result.Status)
+				assert.Equal(t, "Not implemented yet", skipped.Result.Message)
+
+				theories := suites[0].Suites[1]
+				assert.Equal(t, `Parse(input: "1.5")`, theories.Tests[0].Name)
+				assert.Equal(t, "ParseNull", theories.Tests[1].Name)
+
+				expectedTotals := Totals{
+					Tests:      6,
+					Passed:     2,
+					Skipped:    1,
+					Failed:     2,
+					Error:      1,
+					DurationMs: 362,
+				}
+				assert.Equal(t, expectedTotals, suites[0].Totals)
+			},
+		},
+		{
+			title:    "testng example",
+			filename: "testdata/testng.xml",
+			origin:   "https://testng.org/#_logging_and_results",
+			check: func(t *testing.T, suites []Suite) {
+				assert.Len(t, suites, 1)
+				assert.Equal(t, "UI Suite", suites[0].Name)
+				assert.Len(t, suites[0].Suites, 1)
+
+				test := suites[0].Suites[0]
+				assert.Equal(t, "Chrome", test.Name)
+				assert.Len(t, test.Suites, 2)
+
+				login := test.Suites[0]
+				assert.Equal(t, "com.example.LoginTest", login.Name)
+				assert.Len(t, login.Tests, 2)
+
+				passed := login.Tests[0]
+				assert.Equal(t, "login", passed.Name)
+				assert.Equal(t, "com.example.LoginTest", passed.Classname)
+				assert.Equal(t, Status(StatusPassed), passed.Result.Status)
+				assert.Equal(t, int64(340), passed.DurationMs)
+				assert.Equal(t, "admin", passed.Properties["params"])
+				assert.Equal(t, "smoke,regression", passed.Properties["groups"])
+				assert.Equal(t, "opened login page\nlogged in", passed.SystemOut)
+
+				failed := login.Tests[1]
+				assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+				assert.Equal(t, "java.lang.AssertionError", failed.Result.Type)
+				assert.Equal(t, "expected [true] but found [false]", failed.Result.Message)
+				assert.Contains(t, failed.Result.Desc, "LoginTest.java:42")
+
+				cart := test.Suites[1]
+				assert.Len(t, cart.Tests, 2)
+				assert.Equal(t, "openBrowser", cart.Tests[0].Name)
+				assert.Equal(t, Status(StatusError), cart.Tests[0].Result.Status)
+				assert.Equal(t, "org.openqa.selenium.SessionNotCreatedException", cart.Tests[0].Result.Type)
+				assert.Equal(t, Status(StatusSkipped), cart.Tests[1].Result.Status)
+
+				expectedTotals := Totals{
+					Tests:      4,
+					Passed:     1,
+					Skipped:    1,
+					Failed:     1,
+					Error:      1,
+					DurationMs: 3040,
+				}
+				assert.Equal(t, expectedTotals, suites[0].Totals)
+			},
+		},
+		{
+			title:    "surefire rerun example",
+			filename: "testdata/surefire-rerun.xml",
+			origin:   "https://maven.apache.org/surefire/maven-surefire-plugin/examples/rerun-failing-tests.html",
+			check: func(t *testing.T, suites []Suite) {
+				require.Len(t, suites, 1)
+				tests := suites[0].Tests
+				require.Len(t, tests, 4)
+
+				flaky := tests[0]
+				assert.Equal(t, "testFlaky", flaky.Name)
+				assert.Equal(t, Status(StatusPassed), flaky.Result.Status)
+				assert.Equal(t, Status(StatusFlaky), flaky.DerivedStatus())
+				require.Len(t, flaky.Attempts, 2)
+				assert.Equal(t, Status(StatusFailed), flaky.Attempts[0].Result.Status)
+				assert.Equal(t, "expected:<1> but was:<2>", flaky.Attempts[0].Result.Message)
+				assert.Equal(t, "java.lang.AssertionError", flaky.Attempts[0].Result.Type)
+				assert.Contains(t, flaky.Attempts[0].Result.Desc, "FooTest.testFlaky")
+				assert.Equal(t, "first run", flaky.Attempts[0].SystemOut)
+				assert.Equal(t, int64(120), flaky.Attempts[0].DurationMs)
+				assert.Equal(t, Status(StatusError), flaky.Attempts[1].Result.Status)
+				assert.Equal(t, "java.lang.NullPointerException", flaky.Attempts[1].Result.Type)
+
+				rerun := tests[1]
+				assert.Equal(t, "testBroken", rerun.Name)
+				assert.Equal(t, Status(StatusFailed), rerun.Result.Status)
+				assert.Equal(t, Status(StatusFailed), rerun.DerivedStatus())
+				require.Len(t, rerun.Attempts, 1)
+				assert.Equal(t, "still broken", rerun.Attempts[0].Result.Message)
+
+				multiple := tests[2]
+				assert.Equal(t, "testMultiple", multiple.Name)
+				assert.Equal(t, Status(StatusError), multiple.Result.Status)
+				assert.Equal(t, "teardown failed", multiple.Result.Message)
+				require.Len(t, multiple.Failures, 2)
+				assert.Equal(t, "first assertion", multiple.Failures[0].Message)
+				assert.Equal(t, Status(StatusFailed), multiple.Failures[0].Status)
+				assert.Equal(t, multiple.Result, multiple.Failures[1])
+
+				assert.Equal(t, Status(StatusPassed), tests[3].DerivedStatus())
+				assert.Empty(t, tests[3].Failures)
+
+				expectedTotals := Totals{
+					Tests:      4,
+					Passed:     2,
+					Failed:     1,
+					Error:      1,
+					DurationMs: 1200,
+				}
+				assert.Equal(t, expectedTotals, suites[0].Totals)
+			},
+		},
 	}
 
 	for index, test := range tests {
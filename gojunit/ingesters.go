@@ -9,50 +9,135 @@
 package gojunit
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
-	"os"
 )
 
-// IngestFile will parse the given XML file and return a slice of all contained
-// JUnit test suite definitions.
+// IngestFile will parse the given file and return a slice of all contained
+// test suite definitions. The report format is detected from the contents of
+// the file.
 func IngestFile(filename string) ([]Suite, error) {
-	file, err := os.Open(filename)
+	suites, _, err := IngestFileFormat(filename, FormatAuto)
+	return suites, err
+}
+
+// IngestFileFormat will parse the given file as a report of the given format,
+// or of the detected format for FormatAuto, and return a slice of all
+// contained test suite definitions along with the format which was used.
+func IngestFileFormat(filename string, format Format) ([]Suite, Format, error) {
+	return IngestFileOptions(filename, Options{Format: format})
+}
+
+// IngestFileOptions will parse the given file according to the given options,
+// and return a slice of all contained test suite definitions along with the
+// format which was used. The file is opened with OpenFile, so it may be
+// compressed or be the entry of an archive.
+func IngestFileOptions(filename string, opts Options) ([]Suite, Format, error) {
+	file, err := OpenFile(filename)
 	if err != nil {
-		return nil, err
+		return nil, opts.Format, err
 	}
 	defer file.Close()
 
-	return IngestReader(file)
+	if opts.Filename == "" {
+		opts.Filename = filename
+	}
+	suites, format, err := IngestReaderOptions(file, opts)
+	if err != nil {
+		locateError(filename, err)
+	}
+	return suites, format, err
 }
 
-// IngestReader will parse the given XML reader and return a slice of all
-// contained JUnit test suite definitions.
+// IngestReader will parse the given reader and return a slice of all contained
+// test suite definitions. The report format is detected from the contents of
+// the reader.
 func IngestReader(reader io.Reader) ([]Suite, error) {
-	var (
-		suiteChan = make(chan Suite)
-		suites    = make([]Suite, 0)
-	)
+	suites, _, err := IngestReaderFormat(reader, FormatAuto)
+	return suites, err
+}
+
+// IngestReaderFormat will parse the given reader as a report of the given
+// format, or of the detected format for FormatAuto, and return a slice of all
+// contained test suite definitions along with the format which was used.
+func IngestReaderFormat(reader io.Reader, format Format) ([]Suite, Format, error) {
+	return IngestReaderOptions(reader, Options{Format: format})
+}
 
-	nodes, err := parse(reader)
+// IngestReaderOptions will parse the given reader according to the given
+// options, and return a slice of all contained test suite definitions along
+// with the format which was used.
+func IngestReaderOptions(reader io.Reader, opts Options) ([]Suite, Format, error) {
+	format, reader, err := resolveFormat(reader, opts.Format)
 	if err != nil {
-		return nil, err
+		return nil, format, err
 	}
 
-	go func() {
-		findSuites(nodes, suiteChan)
-		close(suiteChan)
-	}()
+	suites, err := ingesters[format](reader, opts)
+	return suites, format, err
+}
 
-	for suite := range suiteChan {
-		suites = append(suites, suite)
+// resolveFormat detects the format of the given reader for FormatAuto, and
+// returns the format along with a reader which still yields the whole report,
+// decoded to UTF-8 by decodeCharset.
+// An error is returned if the format is not supported.
+func resolveFormat(reader io.Reader, format Format) (Format, io.Reader, error) {
+	reader, err := decodeCharset(reader)
+	if err != nil {
+		return format, reader, err
 	}
 
-	return suites, nil
+	if format == FormatAuto {
+		buffered := bufio.NewReaderSize(reader, sniffLen)
+		data, err := buffered.Peek(sniffLen)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return format, reader, err
+		}
+		format, reader = DetectFormat(data), buffered
+	}
+
+	if _, ok := ingesters[format]; !ok {
+		if format == FormatAuto {
+			return format, reader, errors.New("could not detect report format")
+		}
+		return format, reader, fmt.Errorf("unsupported report format %q", format)
+	}
+
+	return format, reader, nil
 }
 
-// Ingest will parse the given XML data and return a slice of all contained
-// JUnit test suite definitions.
+// Ingest will parse the given data and return a slice of all contained test
+// suite definitions. The report format is detected from the data.
 func Ingest(data []byte) ([]Suite, error) {
 	return IngestReader(bytes.NewReader(data))
 }
+
+// xmlIngester returns a function which parses an XML reader and ingests the
+// tags that identify a report of the given format.
+func xmlIngester(format Format) ingester {
+	return func(reader io.Reader, _ Options) ([]Suite, error) {
+		var (
+			suiteChan = make(chan Suite)
+			suites    = make([]Suite, 0)
+		)
+
+		nodes, err := parse(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		go func() {
+			findSuites(nodes, format, suiteChan)
+			close(suiteChan)
+		}()
+
+		for suite := range suiteChan {
+			suites = append(suites, suite)
+		}
+
+		return suites, nil
+	}
+}
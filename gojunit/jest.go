@@ -0,0 +1,154 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// jsClassSeparator separates the titles of nested "describe" blocks in the
+// classname of JavaScript tests.
+const jsClassSeparator = " > "
+
+// ansiEscape matches the terminal color codes which JavaScript test runners
+// include in failure messages.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+type (
+	// jestReport is the output of "jest --json".
+	jestReport struct {
+		TestResults []jestFile `json:"testResults"`
+	}
+
+	// jestFile contains the results of a single test file.
+	jestFile struct {
+		Name             string          `json:"name"`
+		Status           string          `json:"status"`
+		Message          string          `json:"message"`
+		AssertionResults []jestAssertion `json:"assertionResults"`
+	}
+
+	// jestAssertion contains the result of a single test.
+	jestAssertion struct {
+		AncestorTitles  []string `json:"ancestorTitles"`
+		Title           string   `json:"title"`
+		Status          string   `json:"status"`
+		Duration        float64  `json:"duration"`
+		FailureMessages []string `json:"failureMessages"`
+		RetryReasons    []string `json:"retryReasons"`
+	}
+)
+
+// IngestJest will parse the given "jest --json" report and return a slice with
+// a test suite for every test file. The titles of the "describe" blocks of a
+// test are its classname, and a test file which failed to run is reported with
+// a synthesized error test.
+func IngestJest(reader io.Reader) ([]Suite, error) {
+	var report jestReport
+	if err := decodeJSON(reader, &report); err != nil {
+		return nil, err
+	}
+
+	suites := make([]Suite, 0, len(report.TestResults))
+	for i := range report.TestResults {
+		suites = append(suites, ingestJestFile(&report.TestResults[i]))
+	}
+
+	return suites, nil
+}
+
+func ingestJestFile(file *jestFile) Suite {
+	suite := Suite{
+		Name: file.Name,
+	}
+
+	for i := range file.AssertionResults {
+		suite.Tests = append(suite.Tests, ingestJestAssertion(&file.AssertionResults[i], file.Name))
+	}
+
+	suite.Aggregate()
+
+	// Syntax errors, and failures in hooks outside of any test, fail the file
+	// without failing a test.
+	if file.Status == "failed" && suite.Totals.Failed == 0 {
+		message := stripANSI(file.Message)
+		suite.Tests = append(suite.Tests, Test{
+			Name:      "[suite failed]",
+			Classname: file.Name,
+			Filename:  file.Name,
+			Result: Result{
+				Status:  StatusError,
+				Message: firstLine(message),
+				Desc:    message,
+			},
+		})
+		suite.Aggregate()
+	}
+
+	return suite
+}
+
+func ingestJestAssertion(assertion *jestAssertion, filename string) Test {
+	test := Test{
+		Name:       assertion.Title,
+		Classname:  strings.Join(assertion.AncestorTitles, jsClassSeparator),
+		Filename:   filename,
+		DurationMs: int64(assertion.Duration),
+		Result:     Result{Status: jestStatus(assertion.Status)},
+	}
+	if test.Classname == "" {
+		test.Classname = filename
+	}
+
+	if len(assertion.FailureMessages) != 0 {
+		desc := stripANSI(strings.Join(assertion.FailureMessages, "\n"))
+		test.Result.Message = firstLine(desc)
+		test.Result.Desc = desc
+	}
+
+	// Tests retried with jest.retryTimes report the failures of their earlier
+	// attempts.
+	for _, reason := range assertion.RetryReasons {
+		reason = stripANSI(reason)
+		test.Attempts = append(test.Attempts, Attempt{
+			Result: Result{
+				Status:  StatusFailed,
+				Message: firstLine(reason),
+				Desc:    reason,
+			},
+		})
+	}
+
+	return test
+}
+
+// jestStatus maps the status of a Jest test to a status.
+func jestStatus(status string) Status {
+	switch status {
+	case "passed":
+		return StatusPassed
+	case "failed":
+		return StatusFailed
+	default:
+		// Pending, skipped, todo and disabled tests.
+		return StatusSkipped
+	}
+}
+
+// stripANSI removes terminal color codes from the given text.
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// firstLine returns the first non-blank line of the given text.
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestJest(t *testing.T) {
+	file, err := os.Open("testdata/jest.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestJest(file)
+	require.NoError(t, err)
+	require.Len(t, suites, 2)
+
+	cart := suites[0]
+	assert.Equal(t, "/repo/src/cart.test.js", cart.Name)
+	require.Len(t, cart.Tests, 5)
+	assert.Equal(t, Totals{Tests: 5, Passed: 2, Skipped: 2, Failed: 1, DurationMs: 24}, cart.Totals)
+
+	passed := cart.Tests[0]
+	assert.Equal(t, "sums the prices", passed.Name)
+	assert.Equal(t, "cart > totals", passed.Classname)
+	assert.Equal(t, "/repo/src/cart.test.js", passed.Filename)
+	assert.Equal(t, int64(4), passed.DurationMs)
+
+	failed := cart.Tests[1]
+	assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+	assert.Equal(t, "expect(received).toBe(expected)", failed.Result.Message)
+	assert.Contains(t, failed.Result.Desc, "Expected: 5\nReceived: 4")
+	assert.NotContains(t, failed.Result.Desc, "\x1b")
+
+	retried := cart.Tests[2]
+	assert.Equal(t, Status(StatusPassed), retried.Result.Status)
+	require.Len(t, retried.Attempts, 1)
+	assert.Equal(t, Status(StatusFailed), retried.Attempts[0].Result.Status)
+	assert.Equal(t, "Error: connect ECONNREFUSED 127.0.0.1:5432", retried.Attempts[0].Result.Message)
+
+	todo := cart.Tests[3]
+	assert.Equal(t, "/repo/src/cart.test.js", todo.Classname)
+	assert.Equal(t, Status(StatusSkipped), todo.Result.Status)
+
+	broken := suites[1]
+	require.Len(t, broken.Tests, 1)
+	assert.Equal(t, "[suite failed]", broken.Tests[0].Name)
+	assert.Equal(t, Status(StatusError), broken.Tests[0].Result.Status)
+	assert.Equal(t, "● Test suite failed to run", broken.Tests[0].Result.Message)
+	assert.Contains(t, broken.Tests[0].Result.Desc, "SyntaxError: /repo/src/checkout.test.js")
+}
@@ -0,0 +1,257 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"unicode/utf8"
+)
+
+// textState is where a textLimiter is within an XML document.
+type textState int
+
+const (
+	inText textState = iota
+	inMarkup
+	inTag
+	inCDATA
+	inComment
+	inInstruction
+)
+
+// cdataStart is the start of a CDATA section, after its "<".
+const cdataStart = "![CDATA["
+
+// textLimiter reads an XML document for a decoder, and drops the character
+// data of the element which is being captured once it exceeds a limit, before
+// the decoder reads it, so that a huge run of text, such as the CDATA section
+// of a "system-out" tag, is never read into memory in full. As it is an
+// io.ByteReader, the decoder reads it one byte at a time instead of reading
+// ahead of the tokens it returns, so a limit which is set once a start tag was
+// decoded applies from the content of that tag on.
+type textLimiter struct {
+	src   *bufio.Reader
+	limit int
+
+	// kept is the number of bytes of character data which were kept since
+	// the limit was set, and dropped and total are the number of bytes which
+	// were dropped since they were last taken, and overall.
+	kept    int
+	dropped int
+	total   int64
+
+	state    textState
+	dropping bool
+	entity   bool
+	markup   []byte
+	quote    byte
+
+	// repeats is the number of repeated bytes which may end a comment,
+	// processing instruction or CDATA section, of which held were dropped.
+	repeats int
+	held    int
+
+	// pending are bytes which are returned before the next byte is read.
+	pending []byte
+}
+
+// newLimitedXMLDecoder returns a decoder like newXMLDecoder, which reads the
+// given report through the returned limiter.
+func newLimitedXMLDecoder(reader io.Reader) (*xml.Decoder, *textLimiter) {
+	limiter := &textLimiter{src: bufio.NewReader(sanitizeXML(reader))}
+	dec := xml.NewDecoder(limiter)
+	dec.CharsetReader = charsetReader
+	return dec, limiter
+}
+
+// Read implements io.Reader. The decoder only reads single bytes.
+func (l *textLimiter) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b, err := l.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	p[0] = b
+	return 1, nil
+}
+
+// ReadByte implements io.ByteReader.
+func (l *textLimiter) ReadByte() (byte, error) {
+	for len(l.pending) == 0 {
+		b, err := l.src.ReadByte()
+		if err != nil || l.limit == 0 {
+			return b, err
+		}
+		if l.next(b) {
+			return b, nil
+		}
+	}
+	b := l.pending[0]
+	l.pending = l.pending[1:]
+	return b, nil
+}
+
+// setLimit limits the character data which follows to the given number of
+// bytes, or removes the limit if it is zero. It is set once a start or end tag
+// was decoded, and so within text.
+func (l *textLimiter) setLimit(limit int) {
+	if l == nil {
+		return
+	}
+	l.limit, l.kept = limit, 0
+	l.state, l.dropping, l.entity = inText, false, false
+}
+
+// take returns the number of bytes which were dropped since it was last called.
+func (l *textLimiter) take() int {
+	if l == nil {
+		return 0
+	}
+	dropped := l.dropped
+	l.dropped = 0
+	return dropped
+}
+
+// offset returns the offset in the document of the given offset of the decoder.
+func (l *textLimiter) offset(offset int64) int64 {
+	if l == nil {
+		return offset
+	}
+	return offset + l.total
+}
+
+// next advances the state by the given byte, and returns whether it is kept.
+func (l *textLimiter) next(b byte) bool {
+	switch l.state {
+	case inText:
+		if b == '<' {
+			l.state, l.markup = inMarkup, l.markup[:0]
+			l.dropping, l.entity = false, false
+			return true
+		}
+		keep := l.content(b)
+		switch b {
+		case '&':
+			l.entity = true
+		case ';':
+			l.entity = false
+		}
+		return keep
+	case inMarkup:
+		l.markup = append(l.markup, b)
+		markup := string(l.markup)
+		switch {
+		case markup == "?":
+			l.state, l.repeats = inInstruction, 0
+		case markup == "!--":
+			l.state, l.repeats = inComment, 0
+		case markup == cdataStart:
+			l.state, l.repeats, l.held = inCDATA, 0, 0
+		case markup == "!-" || len(markup) < len(cdataStart) && cdataStart[:len(markup)] == markup:
+			// The markup may still start a comment or a CDATA section.
+		default:
+			l.state, l.quote = inTag, 0
+			l.tag(b)
+		}
+		return true
+	case inTag:
+		l.tag(b)
+		return true
+	case inComment:
+		l.delimit(b, '-', 2)
+		return true
+	case inInstruction:
+		l.delimit(b, '?', 1)
+		return true
+	default:
+		return l.cdata(b)
+	}
+}
+
+// content returns whether the given byte of character data is kept. Once the
+// limit is reached, the rest of the text or CDATA section is dropped, starting
+// at a character which is not within a reference, so that what is kept is
+// still well-formed. A reference is counted as a single byte, as most stand
+// for one, while the bytes which are dropped are counted as they are.
+func (l *textLimiter) content(b byte) bool {
+	if !l.dropping && l.kept >= l.limit && utf8.RuneStart(b) && !l.entity {
+		l.dropping = true
+	}
+	if l.dropping {
+		l.dropped++
+		l.total++
+		return false
+	}
+	if !l.entity {
+		l.kept++
+	}
+	return true
+}
+
+// tag advances the state within a tag, whose quoted attribute values may
+// contain a ">".
+func (l *textLimiter) tag(b byte) {
+	switch {
+	case l.quote != 0:
+		if b == l.quote {
+			l.quote = 0
+		}
+	case b == '"' || b == '\'':
+		l.quote = b
+	case b == '>':
+		l.state = inText
+	}
+}
+
+// delimit advances the state within a comment or processing instruction, which
+// ends with the given number of repeats of the given byte and a ">".
+func (l *textLimiter) delimit(b, repeated byte, count int) {
+	switch {
+	case b == repeated:
+		l.repeats++
+	case b == '>' && l.repeats >= count:
+		l.state = inText
+	default:
+		l.repeats = 0
+	}
+}
+
+// cdata advances the state within a CDATA section, and returns whether the
+// given byte is kept. The brackets which end the section are not part of its
+// content, and are restored if they were dropped along with it.
+func (l *textLimiter) cdata(b byte) bool {
+	switch {
+	case b == '>' && l.repeats >= 2:
+		restored := l.held
+		if restored > 2 {
+			restored = 2
+		}
+		l.kept -= 2 - restored
+		l.dropped -= restored
+		l.total -= int64(restored)
+		l.state, l.dropping = inText, false
+		if restored == 0 {
+			return true
+		}
+		l.pending = append(l.pending[:0], "]]>"[2-restored:]...)
+		return false
+	case b == ']':
+		l.repeats++
+	default:
+		l.repeats, l.held = 0, 0
+	}
+
+	if l.content(b) {
+		return true
+	}
+	if b == ']' {
+		l.held++
+	}
+	return false
+}
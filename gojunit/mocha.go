@@ -0,0 +1,207 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+type (
+	// mochaReport is the output of the Mocha "json" reporter, or of the
+	// Mochawesome reporter, which nests the tests in the suites of its results.
+	mochaReport struct {
+		Tests    []mochaTest        `json:"tests"`
+		Pending  []mochaTest        `json:"pending"`
+		Failures []mochaTest        `json:"failures"`
+		Results  []mochawesomeSuite `json:"results"`
+	}
+
+	mochaTest struct {
+		Title        string   `json:"title"`
+		FullTitle    string   `json:"fullTitle"`
+		File         string   `json:"file"`
+		Duration     float64  `json:"duration"`
+		CurrentRetry int      `json:"currentRetry"`
+		Err          mochaErr `json:"err"`
+	}
+
+	mochaErr struct {
+		Name    string `json:"name"`
+		Message string `json:"message"`
+		Stack   string `json:"stack"`
+	}
+
+	// mochawesomeSuite is a suite of a Mochawesome report. Every result is the
+	// root suite of a test file.
+	mochawesomeSuite struct {
+		FullFile    string             `json:"fullFile"`
+		Tests       []mochawesomeTest  `json:"tests"`
+		BeforeHooks []mochawesomeTest  `json:"beforeHooks"`
+		AfterHooks  []mochawesomeTest  `json:"afterHooks"`
+		Suites      []mochawesomeSuite `json:"suites"`
+	}
+
+	mochawesomeTest struct {
+		Title     string         `json:"title"`
+		FullTitle string         `json:"fullTitle"`
+		Duration  float64        `json:"duration"`
+		State     string         `json:"state"`
+		Pending   bool           `json:"pending"`
+		Skipped   bool           `json:"skipped"`
+		IsHook    bool           `json:"isHook"`
+		Err       mochawesomeErr `json:"err"`
+	}
+
+	// mochawesomeErr is the error of a Mochawesome test, whose message starts
+	// with the name of the error.
+	mochawesomeErr struct {
+		Message string `json:"message"`
+		Estack  string `json:"estack"`
+	}
+)
+
+// IngestMocha will parse the given Mocha "json" reporter or Mochawesome output
+// and return a slice with a test suite for every test file. The titles of the
+// "describe" blocks of a test are its classname, and failing hooks, which are
+// not part of the tests, are reported as error tests.
+func IngestMocha(reader io.Reader) ([]Suite, error) {
+	var report mochaReport
+	if err := decodeJSON(reader, &report); err != nil {
+		return nil, err
+	}
+
+	var (
+		suites  []Suite
+		byFile  = make(map[string]int)
+		pending = make(map[string]bool)
+		seen    = make(map[string]bool)
+	)
+	for i := range report.Pending {
+		pending[report.Pending[i].key()] = true
+	}
+
+	add := func(test Test) {
+		index, ok := byFile[test.Filename]
+		if !ok {
+			index = len(suites)
+			byFile[test.Filename] = index
+			suites = append(suites, Suite{Name: test.Filename})
+		}
+		suites[index].Tests = append(suites[index].Tests, test)
+	}
+
+	for i := range report.Tests {
+		test := &report.Tests[i]
+		seen[test.key()] = true
+		add(ingestMochaTest(test, pending[test.key()]))
+	}
+	for i := range report.Failures {
+		if failure := &report.Failures[i]; !seen[failure.key()] {
+			hook := ingestMochaTest(failure, false)
+			hook.Result.Status = StatusError
+			add(hook)
+		}
+	}
+	for i := range report.Results {
+		ingestMochawesomeSuite(&report.Results[i], "", add)
+	}
+
+	for i := range suites {
+		suites[i].Aggregate()
+	}
+
+	return suites, nil
+}
+
+// key identifies a test across the lists of the report.
+func (t *mochaTest) key() string {
+	return t.File + "\x00" + t.FullTitle
+}
+
+func ingestMochaTest(mocha *mochaTest, pending bool) Test {
+	test := Test{
+		Name:       mocha.Title,
+		Classname:  strings.TrimSpace(strings.TrimSuffix(mocha.FullTitle, mocha.Title)),
+		Filename:   mocha.File,
+		DurationMs: int64(mocha.Duration),
+		Result:     Result{Status: StatusPassed},
+	}
+	if test.Classname == "" {
+		test.Classname = mocha.File
+	}
+
+	switch {
+	case pending:
+		test.Result.Status = StatusSkipped
+	case mocha.Err.Message != "" || mocha.Err.Stack != "":
+		test.Result.Status = StatusFailed
+		test.Result.Type = mocha.Err.Name
+		test.Result.Message = stripANSI(mocha.Err.Message)
+		test.Result.Desc = stripANSI(mocha.Err.Stack)
+	}
+
+	// Mocha only reports the number of times a test was retried, and not the
+	// results of the earlier attempts.
+	if mocha.CurrentRetry != 0 {
+		test.Properties = map[string]string{"retries": strconv.Itoa(mocha.CurrentRetry)}
+	}
+
+	return test
+}
+
+// ingestMochawesomeSuite adds the tests of the given Mochawesome suite and of
+// its nested suites, and its failed hooks as error tests.
+func ingestMochawesomeSuite(suite *mochawesomeSuite, file string, add func(Test)) {
+	if suite.FullFile != "" {
+		file = suite.FullFile
+	}
+
+	for i := range suite.Tests {
+		add(suite.Tests[i].test(file))
+	}
+	for _, hooks := range [][]mochawesomeTest{suite.BeforeHooks, suite.AfterHooks} {
+		for i := range hooks {
+			if hooks[i].State == "failed" {
+				hook := hooks[i].test(file)
+				hook.Result.Status = StatusError
+				add(hook)
+			}
+		}
+	}
+	for i := range suite.Suites {
+		ingestMochawesomeSuite(&suite.Suites[i], file, add)
+	}
+}
+
+func (t *mochawesomeTest) test(file string) Test {
+	name, message := splitErrorName(t.Err.Message)
+	test := ingestMochaTest(&mochaTest{
+		Title:     t.Title,
+		FullTitle: t.FullTitle,
+		File:      file,
+		Duration:  t.Duration,
+		Err:       mochaErr{Name: name, Message: message, Stack: t.Err.Estack},
+	}, t.Pending || t.Skipped)
+
+	switch {
+	case t.IsHook && t.State == "failed":
+		test.Result.Status = StatusError
+	case t.State == "failed":
+		test.Result.Status = StatusFailed
+	}
+	return test
+}
+
+// splitErrorName splits the name of the error off a message such as
+// "AssertionError: expected 100 to equal 90".
+func splitErrorName(message string) (string, string) {
+	name, rest, ok := strings.Cut(message, ": ")
+	if !ok || name == "" || strings.ContainsAny(name, " \t\n") {
+		return "", message
+	}
+	return name, rest
+}
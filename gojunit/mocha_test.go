@@ -0,0 +1,88 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestMocha(t *testing.T) {
+	file, err := os.Open("testdata/mocha.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestMocha(file)
+	require.NoError(t, err)
+	require.Len(t, suites, 2)
+
+	dates := suites[0]
+	assert.Equal(t, "/repo/test/dates.spec.js", dates.Name)
+	require.Len(t, dates.Tests, 4)
+	assert.Equal(t, Totals{Tests: 4, Passed: 2, Skipped: 1, Failed: 1, DurationMs: 8}, dates.Totals)
+
+	passed := dates.Tests[0]
+	assert.Equal(t, "parses an ISO date", passed.Name)
+	assert.Equal(t, "dates parse", passed.Classname)
+	assert.Equal(t, Status(StatusPassed), passed.Result.Status)
+
+	failed := dates.Tests[1]
+	assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+	assert.Equal(t, "AssertionError", failed.Result.Type)
+	assert.Equal(t, "expected [Function] to throw an error", failed.Result.Message)
+	assert.Contains(t, failed.Result.Desc, "test/dates.spec.js:18:41")
+	assert.Equal(t, "2", failed.Properties["retries"])
+
+	assert.Equal(t, "dates", dates.Tests[2].Classname)
+	assert.Equal(t, Status(StatusSkipped), dates.Tests[3].Result.Status)
+
+	db := suites[1]
+	require.Len(t, db.Tests, 1)
+	hook := db.Tests[0]
+	assert.Equal(t, `"before all" hook in "db"`, hook.Name)
+	assert.Equal(t, "db", hook.Classname)
+	assert.Equal(t, Status(StatusError), hook.Result.Status)
+	assert.Equal(t, "Timeout of 2000ms exceeded.", hook.Result.Message)
+}
+
+func TestIngestMochawesome(t *testing.T) {
+	suites, format, err := IngestFileFormat("testdata/mochawesome.json", FormatAuto)
+	require.NoError(t, err)
+	assert.Equal(t, FormatMocha, format)
+	require.Len(t, suites, 2)
+
+	cart := suites[0]
+	assert.Equal(t, "/repo/test/cart.spec.js", cart.Name)
+	require.Len(t, cart.Tests, 4)
+	assert.Equal(t, Totals{Tests: 4, Passed: 2, Skipped: 1, Failed: 1, DurationMs: 19}, cart.Totals)
+
+	assert.Equal(t, "cart", cart.Tests[0].Classname)
+	assert.Equal(t, Status(StatusPassed), cart.Tests[0].Result.Status)
+
+	failed := cart.Tests[1]
+	assert.Equal(t, "applies a discount", failed.Name)
+	assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+	assert.Equal(t, "AssertionError", failed.Result.Type)
+	assert.Equal(t, "expected 100 to equal 90", failed.Result.Message)
+	assert.Contains(t, failed.Result.Desc, "test/cart.spec.js:21:34")
+
+	assert.Equal(t, Status(StatusSkipped), cart.Tests[2].Result.Status)
+	assert.Equal(t, "charges the card", cart.Tests[3].Name)
+	assert.Equal(t, "cart checkout", cart.Tests[3].Classname)
+
+	db := suites[1]
+	require.Len(t, db.Tests, 2)
+	assert.Equal(t, Totals{Tests: 2, Skipped: 1, Error: 1, DurationMs: 2000}, db.Totals)
+	assert.Equal(t, Status(StatusSkipped), db.Tests[0].Result.Status)
+	hook := db.Tests[1]
+	assert.Equal(t, `"before all" hook in "db"`, hook.Name)
+	assert.Equal(t, "db", hook.Classname)
+	assert.Equal(t, Status(StatusError), hook.Result.Status)
+	assert.Equal(t, "Error", hook.Result.Type)
+	assert.Equal(t, "Timeout of 2000ms exceeded.", hook.Result.Message)
+}
@@ -21,6 +21,26 @@ func (n *xmlNode) Attr(name string) string {
 	return n.Attrs[name]
 }
 
+// Child returns the first direct child node with the given local name, or nil
+// if there is no such child.
+func (n *xmlNode) Child(name string) *xmlNode {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// ChildContent returns the content of the first direct child node with the
+// given local name, or an empty string if there is no such child.
+func (n *xmlNode) ChildContent(name string) string {
+	if child := n.Child(name); child != nil {
+		return string(child.Content)
+	}
+	return ""
+}
+
 func (n *xmlNode) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	type nodeAlias xmlNode
 	if err := d.DecodeElement((*nodeAlias)(n), &start); err != nil {
@@ -0,0 +1,171 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import "strings"
+
+// findNUnitSuites ingests the top-level "test-suite" tags of an NUnit 3
+// "test-run" or NUnit 2 "test-results" document.
+func findNUnitSuites(root xmlNode, suites chan Suite) { //nolint:gocritic
+	for _, node := range nunitChildren(root) {
+		if node.XMLName.Local == "test-suite" {
+			suites <- ingestNUnitSuite(node)
+		}
+	}
+}
+
+// nunitChildren returns the nested suites and test cases of an NUnit element.
+// NUnit 2 wraps them in a "results" tag, while NUnit 3 does not.
+func nunitChildren(root xmlNode) []xmlNode { //nolint:gocritic
+	if results := root.Child("results"); results != nil {
+		return results.Nodes
+	}
+	return root.Nodes
+}
+
+func ingestNUnitSuite(root xmlNode) Suite { //nolint:gocritic
+	suite := Suite{
+		Name:       root.Attr("name"),
+		Package:    root.Attr("fullname"),
+		Properties: ingestNUnitProperties(root),
+		SystemOut:  root.ChildContent("output"),
+	}
+
+	for _, node := range nunitChildren(root) {
+		switch node.XMLName.Local {
+		case "test-suite":
+			suite.Suites = append(suite.Suites, ingestNUnitSuite(node))
+		case "test-case":
+			suite.Tests = append(suite.Tests, ingestNUnitTestcase(node))
+		}
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+// ingestNUnitProperties merges the attributes of the given node with its
+// "properties" and NUnit 2 "categories" children. Properties which are declared
+// more than once, such as multiple categories, are joined with a comma.
+func ingestNUnitProperties(root xmlNode) map[string]string { //nolint:gocritic
+	declared := make(map[string]string)
+	add := func(name, value string) {
+		if existing, ok := declared[name]; ok {
+			value = existing + "," + value
+		}
+		declared[name] = value
+	}
+
+	if properties := root.Child("properties"); properties != nil {
+		for _, node := range properties.Nodes {
+			if node.XMLName.Local == "property" {
+				add(node.Attr("name"), node.Attr("value"))
+			}
+		}
+	}
+	if categories := root.Child("categories"); categories != nil {
+		for _, node := range categories.Nodes {
+			if node.XMLName.Local == "category" {
+				add("Category", node.Attr("name"))
+			}
+		}
+	}
+
+	props := make(map[string]string, len(root.Attrs)+len(declared))
+	for name, value := range root.Attrs {
+		props[name] = value
+	}
+	for name, value := range declared {
+		props[name] = value
+	}
+	return props
+}
+
+func ingestNUnitTestcase(root xmlNode) Test { //nolint:gocritic
+	name, classname := root.Attr("name"), root.Attr("classname")
+	if classname == "" {
+		// NUnit 2 only records the fully qualified name of the test.
+		classname, name = splitNUnitName(name)
+	}
+
+	test := Test{
+		Name:       name,
+		Classname:  classname,
+		DurationMs: duration(nunitDuration(root)).Milliseconds(),
+		Result:     Result{Status: nunitStatus(root)},
+		Properties: ingestNUnitProperties(root),
+		SystemOut:  root.ChildContent("output"),
+	}
+
+	if failure := root.Child("failure"); failure != nil {
+		test.Result.Message = failure.ChildContent("message")
+		test.Result.Desc = failure.ChildContent("stack-trace")
+	} else if reason := root.Child("reason"); reason != nil {
+		test.Result.Message = reason.ChildContent("message")
+	}
+
+	if test.Result.Status == StatusError {
+		test.Result.Type = nunitExceptionType(test.Result.Message)
+	}
+
+	return test
+}
+
+// nunitStatus maps the result of an NUnit 2 or NUnit 3 test case to a status.
+func nunitStatus(root xmlNode) Status { //nolint:gocritic
+	switch root.Attr("result") {
+	case "Passed", "Success", "Warning":
+		return StatusPassed
+	case "Failed":
+		// NUnit 3 distinguishes errors from failures using the label.
+		switch root.Attr("label") {
+		case "Error", "Cancelled", "Invalid":
+			return StatusError
+		}
+		return StatusFailed
+	case "Failure":
+		return StatusFailed
+	case "Error", "Cancelled", "NotRunnable":
+		return StatusError
+	default:
+		// Skipped, Ignored, Explicit and Inconclusive tests.
+		return StatusSkipped
+	}
+}
+
+// nunitDuration returns the duration of an NUnit 3 ("duration") or NUnit 2
+// ("time") element, in seconds.
+func nunitDuration(root xmlNode) string { //nolint:gocritic
+	if d := root.Attr("duration"); d != "" {
+		return d
+	}
+	return root.Attr("time")
+}
+
+// splitNUnitName splits a fully qualified NUnit 2 test name into its
+// classname and name, ignoring any dots within the test arguments.
+//   - "Tests.MathTests.Add"        →  "Tests.MathTests", "Add"
+//   - "Tests.MathTests.Add(1.5,2)" →  "Tests.MathTests", "Add(1.5,2)"
+func splitNUnitName(fullname string) (string, string) {
+	base := fullname
+	if i := strings.Index(base, "("); i >= 0 {
+		base = base[:i]
+	}
+	i := strings.LastIndex(base, ".")
+	if i < 0 {
+		return "", fullname
+	}
+	return fullname[:i], fullname[i+1:]
+}
+
+// nunitExceptionType extracts the exception type from an NUnit error message,
+// which is formatted as "System.InvalidOperationException : message".
+func nunitExceptionType(message string) string {
+	i := strings.Index(message, " : ")
+	if i <= 0 || strings.ContainsAny(message[:i], " \t\n") {
+		return ""
+	}
+	return message[:i]
+}
@@ -10,7 +10,6 @@ package gojunit
 
 import (
 	"bytes"
-	"encoding/xml"
 	"errors"
 	"html"
 	"io"
@@ -103,15 +102,17 @@ func extractContent(data []byte) ([]byte, error) {
 }
 
 // parse unmarshalls the given XML data into a graph of nodes, and then returns
-// a slice of all top-level nodes.
+// a slice of all top-level nodes. A malformed document is reported with a
+// SyntaxError.
 func parse(reader io.Reader) ([]xmlNode, error) {
 	var (
-		dec  = xml.NewDecoder(reparentXML(reader))
+		dec  = newXMLDecoder(reparentXML(reader))
 		root xmlNode
 	)
 
 	if err := dec.Decode(&root); err != nil {
-		return nil, err
+		// The offset is that of the original reader, without the fake root.
+		return nil, xmlSyntaxError(err, dec.InputOffset()-int64(len("<fake-root>")))
 	}
 
 	return root.Nodes, nil
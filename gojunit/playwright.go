@@ -0,0 +1,223 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type (
+	// playwrightReport is the output of the Playwright "json" reporter.
+	playwrightReport struct {
+		Suites []playwrightSuite `json:"suites"`
+		Errors []playwrightError `json:"errors"`
+	}
+
+	// playwrightSuite is a test file, or a "describe" block within it.
+	playwrightSuite struct {
+		Title  string            `json:"title"`
+		File   string            `json:"file"`
+		Specs  []playwrightSpec  `json:"specs"`
+		Suites []playwrightSuite `json:"suites"`
+	}
+
+	// playwrightSpec is a single test, which is run once for every project.
+	playwrightSpec struct {
+		Title string           `json:"title"`
+		File  string           `json:"file"`
+		Tags  []string         `json:"tags"`
+		Tests []playwrightTest `json:"tests"`
+	}
+
+	// playwrightTest is the run of a spec in a single project, with a result
+	// for each attempt.
+	playwrightTest struct {
+		ProjectName string             `json:"projectName"`
+		Status      string             `json:"status"`
+		Results     []playwrightResult `json:"results"`
+	}
+
+	playwrightResult struct {
+		Status   string             `json:"status"`
+		Duration float64            `json:"duration"`
+		Error    *playwrightError   `json:"error"`
+		Stdout   []playwrightOutput `json:"stdout"`
+		Stderr   []playwrightOutput `json:"stderr"`
+	}
+
+	playwrightError struct {
+		Message string `json:"message"`
+		Stack   string `json:"stack"`
+	}
+
+	// playwrightOutput is a chunk of output, which is either text or a base64
+	// encoded buffer.
+	playwrightOutput struct {
+		Text string `json:"text"`
+	}
+)
+
+// IngestPlaywright will parse the given Playwright "json" reporter output and
+// return a slice with a test suite for every test file, and nested suites for
+// its "describe" blocks. Each spec is reported as a test for every project it
+// ran in, with the project and the path of the spec as its classname, and the
+// results of retries are reported as earlier attempts of the test. Errors
+// outside of any test, such as a failing global setup, are reported as error
+// tests.
+func IngestPlaywright(reader io.Reader) ([]Suite, error) {
+	var report playwrightReport
+	if err := decodeJSON(reader, &report); err != nil {
+		return nil, err
+	}
+
+	suites := make([]Suite, 0, len(report.Suites)+1)
+	for i := range report.Suites {
+		suites = append(suites, ingestPlaywrightSuite(&report.Suites[i], nil))
+	}
+
+	if len(report.Errors) != 0 {
+		suite := Suite{Name: "playwright"}
+		for i := range report.Errors {
+			name := "[global error]"
+			if i > 0 {
+				name = fmt.Sprintf("[global error %d]", i+1)
+			}
+			suite.Tests = append(suite.Tests, Test{
+				Name:      name,
+				Classname: suite.Name,
+				Result:    playwrightErrorResult(StatusError, &report.Errors[i]),
+			})
+		}
+		suite.Aggregate()
+		suites = append(suites, suite)
+	}
+
+	return suites, nil
+}
+
+func ingestPlaywrightSuite(root *playwrightSuite, path []string) Suite {
+	path = append(path[:len(path):len(path)], root.Title)
+	suite := Suite{
+		Name:    root.Title,
+		Package: root.File,
+	}
+
+	for i := range root.Specs {
+		spec := &root.Specs[i]
+		for j := range spec.Tests {
+			suite.Tests = append(suite.Tests, ingestPlaywrightTest(spec, &spec.Tests[j], path))
+		}
+	}
+	for i := range root.Suites {
+		suite.Suites = append(suite.Suites, ingestPlaywrightSuite(&root.Suites[i], path))
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+func ingestPlaywrightTest(spec *playwrightSpec, run *playwrightTest, path []string) Test {
+	classname := path
+	if run.ProjectName != "" {
+		classname = append([]string{run.ProjectName}, path...)
+	}
+
+	test := Test{
+		Name:      spec.Title,
+		Classname: strings.Join(classname, jsClassSeparator),
+		Filename:  spec.File,
+		Result:    Result{Status: playwrightStatus(run.Status, "")},
+	}
+	if len(spec.Tags) != 0 {
+		test.Properties = map[string]string{"tags": strings.Join(spec.Tags, ",")}
+	}
+
+	if len(run.Results) == 0 {
+		return test
+	}
+
+	// Every result but the last is an earlier attempt of a retried test.
+	last := len(run.Results) - 1
+	for i := range run.Results[:last] {
+		result := &run.Results[i]
+		test.Attempts = append(test.Attempts, Attempt{
+			DurationMs: int64(result.Duration),
+			Result:     playwrightResultOf(result, playwrightStatus("", result.Status)),
+			SystemOut:  playwrightText(result.Stdout),
+			SystemErr:  playwrightText(result.Stderr),
+		})
+	}
+
+	final := &run.Results[last]
+	test.DurationMs = int64(final.Duration)
+	test.Result = playwrightResultOf(final, playwrightStatus(run.Status, final.Status))
+	test.SystemOut = playwrightText(final.Stdout)
+	test.SystemErr = playwrightText(final.Stderr)
+
+	return test
+}
+
+func playwrightResultOf(result *playwrightResult, status Status) Result {
+	if result.Error == nil || status == StatusPassed || status == StatusSkipped {
+		return Result{Status: status}
+	}
+	return playwrightErrorResult(status, result.Error)
+}
+
+func playwrightErrorResult(status Status, err *playwrightError) Result {
+	message := stripANSI(err.Message)
+	desc := stripANSI(err.Stack)
+	if desc == "" {
+		desc = message
+	}
+	return Result{
+		Status:  status,
+		Message: firstLine(message),
+		Desc:    desc,
+	}
+}
+
+// playwrightStatus maps the outcome of a test across its attempts, or else the
+// status of a single attempt, to a status. A flaky test passed when retried,
+// and an interrupted test was stopped before it could complete.
+func playwrightStatus(outcome, status string) Status {
+	switch outcome {
+	case "expected", "flaky":
+		if status == "skipped" {
+			return StatusSkipped
+		}
+		return StatusPassed
+	case "skipped":
+		return StatusSkipped
+	case "unexpected":
+		if status == "interrupted" {
+			return StatusError
+		}
+		return StatusFailed
+	}
+
+	switch status {
+	case "passed":
+		return StatusPassed
+	case "skipped":
+		return StatusSkipped
+	case "interrupted":
+		return StatusError
+	default:
+		// Failed and timed out attempts.
+		return StatusFailed
+	}
+}
+
+// playwrightText joins the text chunks of the given output.
+func playwrightText(output []playwrightOutput) string {
+	var text strings.Builder
+	for _, chunk := range output {
+		text.WriteString(chunk.Text)
+	}
+	return text.String()
+}
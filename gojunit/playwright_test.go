@@ -0,0 +1,68 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestPlaywright(t *testing.T) {
+	file, err := os.Open("testdata/playwright.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	suites, err := IngestPlaywright(file)
+	require.NoError(t, err)
+	require.Len(t, suites, 2)
+
+	spec := suites[0]
+	assert.Equal(t, "login.spec.ts", spec.Name)
+	assert.Equal(t, Totals{Tests: 4, Passed: 2, Skipped: 1, Failed: 1, DurationMs: 3400}, spec.Totals)
+	require.Len(t, spec.Tests, 1)
+	require.Len(t, spec.Suites, 1)
+
+	top := spec.Tests[0]
+	assert.Equal(t, "has a title", top.Name)
+	assert.Equal(t, "chromium > login.spec.ts", top.Classname)
+	assert.Equal(t, "login.spec.ts", top.Filename)
+	assert.Equal(t, map[string]string{"tags": "@smoke"}, top.Properties)
+
+	login := spec.Suites[0]
+	assert.Equal(t, "Login", login.Name)
+	require.Len(t, login.Tests, 3)
+
+	failed := login.Tests[0]
+	assert.Equal(t, "rejects a wrong password", failed.Name)
+	assert.Equal(t, "chromium > login.spec.ts > Login", failed.Classname)
+	assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+	assert.Equal(t, "Error: expect(locator).toBeVisible()", failed.Result.Message)
+	assert.Equal(t, int64(1400), failed.DurationMs)
+	assert.Equal(t, "warning: slow network\n", failed.SystemErr)
+	require.Len(t, failed.Attempts, 2)
+	assert.Equal(t, int64(1500), failed.Attempts[0].DurationMs)
+	assert.Equal(t, "Error: expect(locator).toBeVisible()", failed.Attempts[0].Result.Message)
+	assert.Equal(t, "filling form\n", failed.Attempts[0].SystemOut)
+	assert.Equal(t, Status(StatusFailed), failed.Attempts[1].Result.Status)
+	assert.Equal(t, "Test timeout of 30000ms exceeded.", failed.Attempts[1].Result.Message)
+
+	flaky := login.Tests[1]
+	assert.Equal(t, "firefox > login.spec.ts > Login", flaky.Classname)
+	assert.Equal(t, Status(StatusPassed), flaky.Result.Status)
+	assert.Empty(t, flaky.Result.Message)
+	require.Len(t, flaky.Attempts, 1)
+	assert.Equal(t, "Error: page.click: Target closed", flaky.Attempts[0].Result.Message)
+
+	assert.Equal(t, Status(StatusSkipped), login.Tests[2].Result.Status)
+
+	errors := suites[1]
+	require.Len(t, errors.Tests, 1)
+	assert.Equal(t, "[global error]", errors.Tests[0].Name)
+	assert.Equal(t, Status(StatusError), errors.Tests[0].Result.Status)
+	assert.Equal(t, "Error: No tests found in shard 2/2", errors.Tests[0].Result.Desc)
+}
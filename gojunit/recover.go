@@ -0,0 +1,108 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// IncompleteProperty is set to "true" on the suites of a truncated report which
+// were still open where the report ended, and which are therefore incomplete.
+const IncompleteProperty = "incomplete"
+
+// TruncatedType is the type of the error test which is synthesized for a
+// truncated report.
+const TruncatedType = "truncated"
+
+// ingestJUnit ingests a JUnit report. As a truncated report can only be
+// salvaged while it is streamed, the report is collected from a stream when
+// Options.Recover is set.
+func ingestJUnit(reader io.Reader, opts Options) ([]Suite, error) {
+	if !opts.Recover {
+		return xmlIngester(FormatJUnit)(reader, opts)
+	}
+
+	stream := junitStream{
+		opts: opts,
+		handler: StreamHandler{
+			Test: func(SuitePath, *Test) error { return nil },
+		},
+		retain:    true,
+		collected: make([]Suite, 0),
+	}
+	if err := stream.run(newXMLDecoder(reader)); err != nil {
+		return nil, err
+	}
+	return stream.collected, nil
+}
+
+// isTruncation returns whether the given error of an XML decoder was caused by
+// the report ending before all of its tags were closed.
+func isTruncation(err error) bool {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Msg == "unexpected EOF"
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// salvage ends a report which was truncated at the given offset, such as by a
+// test runner which crashed while writing it. Every test which was closed
+// before the truncation has already been passed to the handler, while the test
+// which was being written is dropped. An error test named "<file>: report
+// truncated" is added to the innermost open suite, so that the truncation
+// fails the build instead of going unnoticed, and every open suite is then
+// ended and marked as incomplete with IncompleteProperty.
+func (s *junitStream) salvage(err error, offset int64) error {
+	s.test = nil
+	s.attempt = nil
+	s.failureAt = 0
+	s.text = nil
+	s.props = nil
+
+	// A report truncated before its first suite is reported as a suite of its
+	// own.
+	if len(s.suites) == 0 {
+		frame := &streamSuite{suite: Suite{Name: s.opts.Filename}}
+		if frame.suite.Name != "" {
+			frame.path = SuitePath{frame.suite.Name}
+		}
+		s.suites = append(s.suites, frame)
+	}
+
+	name := "report truncated"
+	if s.opts.Filename != "" {
+		name = s.opts.Filename + ": " + name
+	}
+	test := &Test{
+		Name:      name,
+		Classname: s.suites[len(s.suites)-1].suite.Name,
+		Filename:  s.opts.Filename,
+		Result: Result{
+			Status:  StatusError,
+			Type:    TruncatedType,
+			Message: fmt.Sprintf("report truncated at offset %d", offset),
+			Desc:    err.Error(),
+		},
+	}
+	if err := s.endTest(test); err != nil {
+		return err
+	}
+
+	for len(s.suites) != 0 {
+		suite := &s.suites[len(s.suites)-1].suite
+		if suite.Properties == nil {
+			suite.Properties = make(map[string]string)
+		}
+		suite.Properties[IncompleteProperty] = "true"
+		if err := s.endSuite(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
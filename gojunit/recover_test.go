@@ -0,0 +1,102 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverTruncatedJUnit(t *testing.T) {
+	input := `<testsuites>
+	<testsuite name="Outer">
+		<testcase name="one"/>
+		<testsuite name="Inner">
+			<testcase name="two"><failure message="boom"/></testcase>
+			<testcase name="three"><system-out>partial`
+
+	suites, _, err := IngestReaderOptions(strings.NewReader(input), Options{
+		Format:   FormatJUnit,
+		Recover:  true,
+		Filename: "TEST-a.xml",
+	})
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+
+	outer := suites[0]
+	assert.Equal(t, "Outer", outer.Name)
+	assert.Equal(t, "true", outer.Properties[IncompleteProperty])
+	assert.Equal(t, Totals{Tests: 3, Passed: 1, Failed: 1, Error: 1}, outer.Totals)
+	require.Len(t, outer.Tests, 1)
+	assert.Equal(t, "one", outer.Tests[0].Name)
+
+	require.Len(t, outer.Suites, 1)
+	inner := outer.Suites[0]
+	assert.Equal(t, "true", inner.Properties[IncompleteProperty])
+	require.Len(t, inner.Tests, 2)
+	assert.Equal(t, "two", inner.Tests[0].Name)
+
+	truncated := inner.Tests[1]
+	assert.Equal(t, "TEST-a.xml: report truncated", truncated.Name)
+	assert.Equal(t, "Inner", truncated.Classname)
+	assert.Equal(t, Status(StatusError), truncated.Result.Status)
+	assert.Equal(t, TruncatedType, truncated.Result.Type)
+	assert.Contains(t, truncated.Result.Desc, "unexpected EOF")
+}
+
+func TestRecoverTruncatedBeforeSuite(t *testing.T) {
+	var paths []string
+	err := StreamJUnit(strings.NewReader(`<?xml version="1.0"?><testsui`), Options{Recover: true, Filename: "TEST-a.xml"}, StreamHandler{
+		Test: func(path SuitePath, test *Test) error {
+			paths = append(paths, path.String()+" "+test.Name)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TEST-a.xml TEST-a.xml: report truncated"}, paths)
+}
+
+func TestRecoverOnlyTruncation(t *testing.T) {
+	// Reports which are malformed rather than truncated are still rejected.
+	_, _, err := IngestReaderOptions(strings.NewReader(`<testsuite><testcase></testsuite>`), Options{
+		Format:  FormatJUnit,
+		Recover: true,
+	})
+	var syntaxErr *SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+}
+
+func TestRecoverMatchesIngest(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.xml")
+	require.NoError(t, err)
+
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		if DetectFormat(data) != FormatJUnit {
+			continue
+		}
+
+		t.Run(filename, func(t *testing.T) {
+			expected, _, err := IngestReaderOptions(strings.NewReader(string(data)), Options{Format: FormatJUnit})
+			require.NoError(t, err)
+			actual, _, err := IngestReaderOptions(strings.NewReader(string(data)), Options{Format: FormatJUnit, Recover: true})
+			require.NoError(t, err)
+
+			require.Len(t, actual, len(expected))
+			for i := range expected {
+				assert.Equal(t, expected[i].Name, actual[i].Name)
+				assert.Equal(t, expected[i].Totals, actual[i].Totals)
+				assert.Equal(t, len(expected[i].Tests), len(actual[i].Tests))
+				assert.Equal(t, len(expected[i].Suites), len(actual[i].Suites))
+			}
+		})
+	}
+}
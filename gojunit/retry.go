@@ -0,0 +1,170 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RetryPolicy decides which execution of a test which was run more than once,
+// such as by the Gradle test retry plugin or pytest-rerunfailures, is the result
+// of the test.
+type RetryPolicy string
+
+const (
+	// RetryNone leaves every execution of a test as a test of its own.
+	RetryNone RetryPolicy = ""
+
+	// RetryLast resolves a test to its last execution.
+	RetryLast RetryPolicy = "last"
+
+	// RetryAnyPass resolves a test to its last execution which passed, if any
+	// passed, and otherwise to its last execution which failed or had an error,
+	// so that a failure is not hidden by a later execution which was skipped.
+	RetryAnyPass RetryPolicy = "any-pass"
+
+	// RetryAnyFail resolves a test to its last execution which failed or had an
+	// error, if any did, and otherwise to its last execution.
+	RetryAnyFail RetryPolicy = "any-fail"
+)
+
+// ParseRetryPolicy returns the retry policy with the given name. An empty name,
+// or "none", returns RetryNone.
+func ParseRetryPolicy(name string) (RetryPolicy, error) {
+	switch policy := RetryPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case RetryNone, "none":
+		return RetryNone, nil
+	case RetryLast, RetryAnyPass, RetryAnyFail:
+		return policy, nil
+	default:
+		return RetryNone, fmt.Errorf("unsupported retry policy %q", name)
+	}
+}
+
+// ParamsProperty is the property which holds the parameters of a test, such as
+// the values of a TestNG data provider.
+const ParamsProperty = "params"
+
+// TestID is the stable identity of a test, which is shared by all of its
+// executions across reports.
+type TestID struct {
+	Suite      string
+	Classname  string
+	Name       string
+	Filename   string
+	Parameters string
+}
+
+// IDOf returns the identity of the given test of the suite with the given
+// path. The parameters of a test are taken from its ParamsProperty, as most
+// runners of parameterized tests already include them in its name, and the
+// path keeps apart the same class run by several suites, such as the "test"
+// tags of TestNG.
+func IDOf(path SuitePath, test *Test) TestID {
+	return TestID{
+		Suite:      path.String(),
+		Classname:  test.Classname,
+		Name:       test.Name,
+		Filename:   test.Filename,
+		Parameters: test.Properties[ParamsProperty],
+	}
+}
+
+// execution is a single execution of a test, along with the path of its suite.
+type execution struct {
+	path SuitePath
+	test Test
+}
+
+// RetryMerger groups the executions of tests by their identity, and merges
+// every group into a single test according to a retry policy.
+type RetryMerger struct {
+	policy     RetryPolicy
+	ids        []TestID
+	executions map[TestID][]execution
+}
+
+// NewRetryMerger returns a merger which resolves tests with the given policy.
+func NewRetryMerger(policy RetryPolicy) *RetryMerger {
+	return &RetryMerger{
+		policy:     policy,
+		executions: make(map[TestID][]execution),
+	}
+}
+
+// Add adds an execution of a test. Executions are expected to be added in the
+// order in which they ran.
+func (m *RetryMerger) Add(path SuitePath, test *Test) {
+	id := IDOf(path, test)
+	if _, ok := m.executions[id]; !ok {
+		m.ids = append(m.ids, id)
+	}
+	m.executions[id] = append(m.executions[id], execution{path: path, test: *test})
+}
+
+// Walk calls fn with every merged test, in the order in which each test was
+// first added, along with the path of its suite and the number of times it was
+// executed. The merged test is the execution chosen by the policy, and every
+// other execution precedes the attempts of the chosen one in its Attempts, so
+// that a test which passed after it failed is flaky.
+func (m *RetryMerger) Walk(fn func(path SuitePath, test *Test, executions int)) {
+	for _, id := range m.ids {
+		executions := m.executions[id]
+		chosen := m.choose(executions)
+
+		test := executions[chosen].test
+		var attempts []Attempt
+		for i := range executions {
+			if i == chosen {
+				continue
+			}
+			other := &executions[i].test
+			attempts = append(attempts, other.Attempts...)
+			attempts = append(attempts, Attempt{
+				DurationMs: other.DurationMs,
+				Result:     other.Result,
+				SystemOut:  other.SystemOut,
+				SystemErr:  other.SystemErr,
+			})
+		}
+		if len(attempts) != 0 {
+			test.Attempts = append(attempts, test.Attempts...)
+		}
+
+		fn(executions[chosen].path, &test, len(executions))
+	}
+}
+
+// choose returns the index of the execution which the test resolves to.
+func (m *RetryMerger) choose(executions []execution) int {
+	switch m.policy {
+	case RetryAnyPass:
+		if i := lastWithStatus(executions, StatusPassed); i >= 0 {
+			return i
+		}
+		if i := lastWithStatus(executions, StatusFailed, StatusError); i >= 0 {
+			return i
+		}
+	case RetryAnyFail:
+		if i := lastWithStatus(executions, StatusFailed, StatusError); i >= 0 {
+			return i
+		}
+	}
+	return len(executions) - 1
+}
+
+// lastWithStatus returns the index of the last execution with any of the given
+// statuses, or -1 if there is none.
+func lastWithStatus(executions []execution, statuses ...Status) int {
+	for i := len(executions) - 1; i >= 0; i-- {
+		for _, status := range statuses {
+			if executions[i].test.Result.Status == status {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryPolicy(t *testing.T) {
+	for name, expected := range map[string]RetryPolicy{
+		"":         RetryNone,
+		"none":     RetryNone,
+		"last":     RetryLast,
+		"Any-Pass": RetryAnyPass,
+		"any-fail": RetryAnyFail,
+	} {
+		policy, err := ParseRetryPolicy(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, policy, name)
+	}
+
+	_, err := ParseRetryPolicy("first")
+	assert.EqualError(t, err, `unsupported retry policy "first"`)
+}
+
+func TestRetryMerger(t *testing.T) {
+	// Each test is executed in the given order of statuses.
+	executions := map[string][]Status{
+		"once":           {StatusPassed},
+		"fail then pass": {StatusFailed, StatusPassed},
+		"pass then fail": {StatusPassed, StatusError},
+		"always fail":    {StatusFailed, StatusFailed},
+		"fail then skip": {StatusFailed, StatusSkipped},
+	}
+	order := []string{"once", "fail then pass", "pass then fail", "always fail", "fail then skip"}
+
+	tests := map[RetryPolicy]map[string]Status{
+		RetryLast: {
+			"once":           StatusPassed,
+			"fail then pass": StatusFlaky,
+			"pass then fail": StatusError,
+			"always fail":    StatusFailed,
+			"fail then skip": StatusSkipped,
+		},
+		RetryAnyPass: {
+			"once":           StatusPassed,
+			"fail then pass": StatusFlaky,
+			"pass then fail": StatusFlaky,
+			"always fail":    StatusFailed,
+			"fail then skip": StatusFailed,
+		},
+		RetryAnyFail: {
+			"once":           StatusPassed,
+			"fail then pass": StatusFailed,
+			"pass then fail": StatusError,
+			"always fail":    StatusFailed,
+			"fail then skip": StatusFailed,
+		},
+	}
+
+	for policy, expected := range tests {
+		t.Run(string(policy), func(t *testing.T) {
+			merger := NewRetryMerger(policy)
+			for run := 0; run < 2; run++ {
+				for _, name := range order {
+					if run < len(executions[name]) {
+						merger.Add(SuitePath{"suite"}, &Test{
+							Name:       name,
+							Classname:  "Class",
+							DurationMs: int64(run),
+							Result:     Result{Status: executions[name][run]},
+						})
+					}
+				}
+			}
+
+			var names []string
+			merger.Walk(func(path SuitePath, test *Test, count int) {
+				names = append(names, test.Name)
+				assert.Equal(t, SuitePath{"suite"}, path)
+				assert.Equal(t, len(executions[test.Name]), count, test.Name)
+				assert.Len(t, test.Attempts, count-1, test.Name)
+				assert.Equal(t, expected[test.Name], test.DerivedStatus(), test.Name)
+			})
+			assert.Equal(t, order, names)
+		})
+	}
+}
+
+func TestRetryMergerIdentity(t *testing.T) {
+	merger := NewRetryMerger(RetryLast)
+	merger.Add(nil, &Test{Name: "a", Classname: "A", Result: Result{Status: StatusFailed}})
+	merger.Add(nil, &Test{Name: "a", Classname: "B", Result: Result{Status: StatusPassed}})
+	merger.Add(nil, &Test{Name: "a", Classname: "A", Filename: "a.py", Result: Result{Status: StatusPassed}})
+	merger.Add(nil, &Test{Name: "a", Classname: "A", Properties: map[string]string{ParamsProperty: "1"}, Result: Result{Status: StatusPassed}})
+	merger.Add(SuitePath{"Firefox"}, &Test{Name: "a", Classname: "A", Result: Result{Status: StatusPassed}})
+	merger.Add(nil, &Test{
+		Name:      "a",
+		Classname: "A",
+		Result:    Result{Status: StatusPassed},
+		Attempts:  []Attempt{{Result: Result{Status: StatusError, Message: "rerun"}}},
+	})
+
+	var merged []Test
+	merger.Walk(func(_ SuitePath, test *Test, _ int) {
+		merged = append(merged, *test)
+	})
+	require.Len(t, merged, 5)
+
+	// The earlier executions precede the attempts of the chosen one.
+	require.Len(t, merged[0].Attempts, 2)
+	assert.Equal(t, Status(StatusFailed), merged[0].Attempts[0].Result.Status)
+	assert.Equal(t, "rerun", merged[0].Attempts[1].Result.Message)
+	assert.Equal(t, Status(StatusFlaky), merged[0].DerivedStatus())
+}
+
+// TestRetryMergerTestNG checks that the data provider rows of a TestNG method,
+// and the same class run by several "test" tags, are kept apart, while the
+// retries of a method are merged.
+func TestRetryMergerTestNG(t *testing.T) {
+	suites, err := IngestFile("testdata/testng-dataprovider.xml")
+	require.NoError(t, err)
+
+	merger := NewRetryMerger(RetryAnyPass)
+	WalkTests(suites, merger.Add)
+
+	type merged struct {
+		path       string
+		name       string
+		params     string
+		status     Status
+		executions int
+	}
+	var actual []merged
+	merger.Walk(func(path SuitePath, test *Test, executions int) {
+		actual = append(actual, merged{path.String(), test.Name, test.Properties[ParamsProperty], test.DerivedStatus(), executions})
+	})
+
+	assert.Equal(t, []merged{
+		{"UI Suite/Chrome/com.example.LoginTest", "login", "admin", StatusPassed, 1},
+		{"UI Suite/Chrome/com.example.LoginTest", "login", "guest", StatusFailed, 1},
+		{"UI Suite/Firefox/com.example.LoginTest", "login", "admin", StatusPassed, 1},
+		{"UI Suite/Firefox/com.example.LoginTest", "login", "guest", StatusPassed, 1},
+		{"UI Suite/Firefox/com.example.LoginTest", "logout", "", StatusPassed, 2},
+	}, actual)
+}
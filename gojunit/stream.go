@@ -0,0 +1,447 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// StreamHandler receives the tests and suites of a report as they are parsed.
+type StreamHandler struct {
+	// Test is called with every test, along with the path of its suite.
+	Test func(path SuitePath, test *Test) error
+
+	// Suite, if set, is called with every suite once all of its tests and
+	// nested suites have been passed to the handler. The suite does not
+	// contain its tests and nested suites, although its totals include them.
+	Suite func(path SuitePath, suite *Suite) error
+}
+
+// streamSuite is a "testsuite" tag which is being streamed.
+type streamSuite struct {
+	suite Suite
+	path  SuitePath
+	depth int
+}
+
+// capture accumulates the character data of a tag into a string, dropping
+// whatever exceeds its limit.
+type capture struct {
+	dst     *string
+	text    strings.Builder
+	limit   int
+	dropped int
+	depth   int
+}
+
+// StreamFile will parse the given file according to the given options, and
+// pass its tests and suites to the handler. The file is opened with OpenFile,
+// so it may be compressed or be the entry of an archive. JUnit reports are
+// streamed with StreamJUnit, while reports of other formats are ingested in
+// full first. The format which was used is returned.
+func StreamFile(filename string, opts Options, handler StreamHandler) (Format, error) {
+	file, err := OpenFile(filename)
+	if err != nil {
+		return opts.Format, err
+	}
+	defer file.Close()
+
+	if opts.Filename == "" {
+		opts.Filename = filename
+	}
+	format, err := StreamReader(file, opts, handler)
+	if err != nil {
+		locateError(filename, err)
+	}
+	return format, err
+}
+
+// StreamReader will parse the given reader according to the given options, and
+// pass its tests and suites to the handler. JUnit reports are streamed with
+// StreamJUnit, while reports of other formats are ingested in full first. The
+// format which was used is returned.
+func StreamReader(reader io.Reader, opts Options, handler StreamHandler) (Format, error) {
+	format, reader, err := resolveFormat(reader, opts.Format)
+	if err != nil {
+		return format, err
+	}
+
+	if format == FormatJUnit {
+		return format, StreamJUnit(reader, opts, handler)
+	}
+
+	suites, err := ingesters[format](reader, opts)
+	if err != nil {
+		return format, err
+	}
+
+	limit := opts.MaxOutputBytes
+	limited := StreamHandler{
+		Test: func(path SuitePath, test *Test) error {
+			test.SystemOut = truncateOutput(test.SystemOut, limit)
+			test.SystemErr = truncateOutput(test.SystemErr, limit)
+			for i := range test.Attempts {
+				attempt := &test.Attempts[i]
+				attempt.SystemOut = truncateOutput(attempt.SystemOut, limit)
+				attempt.SystemErr = truncateOutput(attempt.SystemErr, limit)
+			}
+			return handler.Test(path, test)
+		},
+	}
+	if handler.Suite != nil {
+		limited.Suite = func(path SuitePath, suite *Suite) error {
+			suite.SystemOut = truncateOutput(suite.SystemOut, limit)
+			suite.SystemErr = truncateOutput(suite.SystemErr, limit)
+			return handler.Suite(path, suite)
+		}
+	}
+
+	for i := range suites {
+		if err := walkSuite(&suites[i], nil, limited); err != nil {
+			return format, err
+		}
+	}
+	return format, nil
+}
+
+// junitStream holds the state of a JUnit report while it is being streamed.
+type junitStream struct {
+	opts      Options
+	handler   StreamHandler
+	elements  []string
+	suites    []*streamSuite
+	test      *Test
+	testAt    int
+	attempt   *Attempt
+	attemptAt int
+	failures  []Result
+	failureAt int
+	text      *capture
+	limiter   *textLimiter
+	props     map[string]string
+	propsAt   int
+
+	// retain keeps the tests and nested suites of every suite, and collects
+	// the top-level suites, when the whole report is ingested.
+	retain    bool
+	collected []Suite
+}
+
+// StreamJUnit will parse the given JUnit XML report token by token, and pass
+// every test to the handler as soon as its "testcase" tag ends, and every suite
+// as soon as its "testsuite" tag ends. Tests and suites are not retained once
+// they are passed to the handler, and the captured stdout and stderr of each
+// is limited to Options.MaxOutputBytes, so that memory use does not grow with
+// the size of the report. The output beyond the limit is dropped before the XML
+// decoder reads it, so that a single huge run of text is not read into memory
+// either.
+//
+// A report which ends before all of its tags are closed is rejected, unless
+// Options.Recover is set, in which case the report is salvaged as described by
+// salvage.
+func StreamJUnit(reader io.Reader, opts Options, handler StreamHandler) error {
+	stream := junitStream{opts: opts, handler: handler}
+	if opts.MaxOutputBytes <= 0 {
+		return stream.run(newXMLDecoder(reader))
+	}
+	dec, limiter := newLimitedXMLDecoder(reader)
+	stream.limiter = limiter
+	return stream.run(dec)
+}
+
+// run streams the tokens of the given decoder until the report ends.
+func (s *junitStream) run(dec *xml.Decoder) error {
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && s.opts.Recover && isTruncation(err) {
+			return s.salvage(err, s.limiter.offset(dec.InputOffset()))
+		}
+		if err != nil {
+			return xmlSyntaxError(err, s.limiter.offset(dec.InputOffset()))
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			s.start(token)
+		case xml.CharData:
+			if s.text != nil {
+				s.text.write(token)
+				s.text.dropped += s.limiter.take()
+			}
+		case xml.EndElement:
+			if err := s.end(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// start handles a start tag, mirroring ingestSuite and ingestTestcase.
+func (s *junitStream) start(token xml.StartElement) {
+	var (
+		name   = token.Name.Local
+		parent string
+		suite  *streamSuite
+		test   = s.test
+	)
+	if len(s.elements) != 0 {
+		parent = s.elements[len(s.elements)-1]
+	}
+	if len(s.suites) != 0 {
+		suite = s.suites[len(s.suites)-1]
+	}
+	s.elements = append(s.elements, name)
+	depth := len(s.elements)
+
+	switch {
+	case s.text != nil:
+		// Tags within captured text are part of the text.
+	case name == "testsuite" && (suite == nil || parent == "testsuite" && test == nil):
+		frame := &streamSuite{
+			suite: Suite{
+				Name:       attr(token, "name"),
+				Package:    attr(token, "package"),
+				Properties: attrMap(token.Attr),
+			},
+			depth: depth,
+		}
+		if suite != nil {
+			frame.path = suite.path
+		}
+		if frame.suite.Name != "" {
+			frame.path = append(frame.path[:len(frame.path):len(frame.path)], frame.suite.Name)
+		}
+		s.suites = append(s.suites, frame)
+	case suite == nil:
+		// Tags outside of any suite are ignored.
+	case name == "testcase" && parent == "testsuite" && test == nil:
+		s.test = &Test{
+			Name:       attr(token, "name"),
+			Classname:  attr(token, "classname"),
+			Filename:   attr(token, "file"),
+			DurationMs: duration(attr(token, "time")).Milliseconds(),
+			Result:     Result{Status: StatusPassed},
+			Properties: attrMap(token.Attr),
+		}
+		s.testAt = depth
+		s.failures = nil
+	case name == "properties" && parent == "testsuite" && test == nil:
+		s.props = make(map[string]string)
+		s.propsAt = depth
+		suite.suite.Properties = s.props
+	case name == "property" && parent == "properties" && s.props != nil:
+		s.props[attr(token, "name")] = attr(token, "value")
+	case name == "system-out" && parent == "testcase" && test != nil:
+		s.startCapture(&test.SystemOut, s.opts.MaxOutputBytes, depth)
+	case name == "system-err" && parent == "testcase" && test != nil:
+		s.startCapture(&test.SystemErr, s.opts.MaxOutputBytes, depth)
+	case s.attempt != nil && depth == s.attemptAt+1:
+		switch name {
+		case "stackTrace":
+			s.startCapture(&s.attempt.Result.Desc, 0, depth)
+		case "system-out":
+			s.startCapture(&s.attempt.SystemOut, s.opts.MaxOutputBytes, depth)
+		case "system-err":
+			s.startCapture(&s.attempt.SystemErr, s.opts.MaxOutputBytes, depth)
+		}
+	case rerunStatuses[name] != "" && parent == "testcase" && test != nil:
+		s.attempt = &Attempt{
+			DurationMs: duration(attr(token, "time")).Milliseconds(),
+			Result: Result{
+				Status:  rerunStatuses[name],
+				Message: attr(token, "message"),
+				Type:    attr(token, "type"),
+			},
+		}
+		s.attemptAt = depth
+	case name == "system-out" && parent == "testsuite" && test == nil:
+		s.startCapture(&suite.suite.SystemOut, s.opts.MaxOutputBytes, depth)
+	case name == "system-err" && parent == "testsuite" && test == nil:
+		s.startCapture(&suite.suite.SystemErr, s.opts.MaxOutputBytes, depth)
+	case parent == "testcase" && test != nil:
+		switch name {
+		case "skipped":
+			test.Result.Status = StatusSkipped
+		case "failure":
+			test.Result.Status = StatusFailed
+			test.Result.Type = attr(token, "type")
+		case "error":
+			test.Result.Status = StatusError
+			test.Result.Type = attr(token, "type")
+		default:
+			return
+		}
+		test.Result.Message = attr(token, "message")
+		s.startCapture(&test.Result.Desc, 0, depth)
+		if name != "skipped" {
+			s.failureAt = depth
+		}
+	}
+}
+
+// startCapture captures the text of the tag at the given depth into dst, which
+// is limited to the given number of bytes unless it is zero.
+func (s *junitStream) startCapture(dst *string, limit, depth int) {
+	s.text = &capture{dst: dst, limit: limit, depth: depth}
+	s.limiter.setLimit(limit)
+}
+
+// end handles an end tag, and passes the test or suite it ends to the handler.
+func (s *junitStream) end() error {
+	depth := len(s.elements)
+	s.elements = s.elements[:depth-1]
+
+	switch {
+	case s.text != nil:
+		if depth != s.text.depth {
+			return nil
+		}
+		s.text.dropped += s.limiter.take()
+		s.text.finish()
+		s.text = nil
+		s.limiter.setLimit(0)
+		if depth == s.failureAt {
+			s.failures = append(s.failures, s.test.Result)
+			s.failureAt = 0
+		}
+	case s.attempt != nil && depth == s.attemptAt:
+		s.test.Attempts = append(s.test.Attempts, *s.attempt)
+		s.attempt = nil
+	case s.test != nil && depth == s.testAt:
+		test := s.test
+		s.test = nil
+		if len(s.failures) > 1 {
+			test.Failures = s.failures
+		}
+		return s.endTest(test)
+	case s.props != nil && depth == s.propsAt:
+		s.props = nil
+	case len(s.suites) != 0 && depth == s.suites[len(s.suites)-1].depth:
+		return s.endSuite()
+	}
+	return nil
+}
+
+// endTest adds the given test to the innermost suite, and passes it to the
+// handler.
+func (s *junitStream) endTest(test *Test) error {
+	suite := s.suites[len(s.suites)-1]
+	countTest(&suite.suite.Totals, test)
+	if err := s.handler.Test(suite.path, test); err != nil {
+		return err
+	}
+	if s.retain {
+		suite.suite.Tests = append(suite.suite.Tests, *test)
+	}
+	return nil
+}
+
+// endSuite ends the innermost suite, adds its totals to those of its parent,
+// and passes it to the handler.
+func (s *junitStream) endSuite() error {
+	suite := s.suites[len(s.suites)-1]
+	s.suites = s.suites[:len(s.suites)-1]
+
+	var parent *Suite
+	if len(s.suites) != 0 {
+		parent = &s.suites[len(s.suites)-1].suite
+		addTotals(&parent.Totals, &suite.suite.Totals)
+	}
+	if s.handler.Suite != nil {
+		if err := s.handler.Suite(suite.path, &suite.suite); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !s.retain:
+	case parent != nil:
+		parent.Suites = append(parent.Suites, suite.suite)
+	default:
+		s.collected = append(s.collected, suite.suite)
+	}
+	return nil
+}
+
+// attr returns the value of the named attribute of the given tag.
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (c *capture) write(data []byte) {
+	if c.limit > 0 && c.dropped == 0 && c.text.Len()+len(data) > c.limit {
+		room := c.limit - c.text.Len()
+		// Avoid splitting a multi-byte character.
+		for room > 0 && !utf8.RuneStart(data[room]) {
+			room--
+		}
+		c.dropped = len(data) - room
+		c.text.Write(data[:room])
+		return
+	}
+	if c.dropped > 0 {
+		c.dropped += len(data)
+		return
+	}
+	c.text.Write(data)
+}
+
+func (c *capture) finish() {
+	*c.dst = c.text.String()
+	if c.dropped > 0 {
+		*c.dst += fmt.Sprintf("\n[truncated %d bytes]", c.dropped)
+	}
+}
+
+// truncateOutput limits the given output to the given number of bytes, unless
+// the limit is zero.
+func truncateOutput(output string, limit int) string {
+	if limit <= 0 || len(output) <= limit {
+		return output
+	}
+	c := capture{dst: &output, limit: limit}
+	c.write([]byte(output))
+	c.finish()
+	return output
+}
+
+// countTest adds the given test to the totals, as Suite.Aggregate does.
+func countTest(totals *Totals, test *Test) {
+	totals.Tests++
+	totals.DurationMs += test.DurationMs
+	switch test.Result.Status {
+	case StatusPassed:
+		totals.Passed++
+	case StatusSkipped:
+		totals.Skipped++
+	case StatusFailed:
+		totals.Failed++
+	case StatusError:
+		totals.Error++
+	}
+}
+
+// addTotals adds the totals of a nested suite to those of its parent.
+func addTotals(totals, nested *Totals) {
+	totals.Tests += nested.Tests
+	totals.DurationMs += nested.DurationMs
+	totals.Passed += nested.Passed
+	totals.Skipped += nested.Skipped
+	totals.Failed += nested.Failed
+	totals.Error += nested.Error
+}
@@ -0,0 +1,272 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamedTest is a test along with the path of its suite.
+type streamedTest struct {
+	path string
+	test Test
+}
+
+func sortStreamed(tests []streamedTest) {
+	sort.SliceStable(tests, func(i, j int) bool {
+		if tests[i].path != tests[j].path {
+			return tests[i].path < tests[j].path
+		}
+		return tests[i].test.Name < tests[j].test.Name
+	})
+}
+
+func TestStreamJUnitMatchesIngest(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.xml")
+	require.NoError(t, err)
+
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		if DetectFormat(data) != FormatJUnit {
+			continue
+		}
+
+		t.Run(filename, func(t *testing.T) {
+			suites, _, err := IngestReaderFormat(strings.NewReader(string(data)), FormatJUnit)
+			require.NoError(t, err)
+
+			var ingested, streamed []streamedTest
+			WalkTests(suites, func(path SuitePath, test *Test) {
+				ingested = append(ingested, streamedTest{path.String(), *test})
+			})
+
+			var totals Totals
+			err = StreamJUnit(strings.NewReader(string(data)), Options{}, StreamHandler{
+				Test: func(path SuitePath, test *Test) error {
+					streamed = append(streamed, streamedTest{path.String(), *test})
+					return nil
+				},
+				Suite: func(path SuitePath, suite *Suite) error {
+					if len(path) == 1 {
+						addTotals(&totals, &suite.Totals)
+					}
+					return nil
+				},
+			})
+			require.NoError(t, err)
+
+			sortStreamed(ingested)
+			sortStreamed(streamed)
+			require.Len(t, streamed, len(ingested))
+			for i := range ingested {
+				assert.Equal(t, ingested[i].path, streamed[i].path)
+				assert.Equal(t, ingested[i].test.Name, streamed[i].test.Name)
+				assert.Equal(t, ingested[i].test.Classname, streamed[i].test.Classname)
+				assert.Equal(t, ingested[i].test.DurationMs, streamed[i].test.DurationMs)
+				assert.Equal(t, ingested[i].test.Result, streamed[i].test.Result)
+				assert.Equal(t, ingested[i].test.Properties, streamed[i].test.Properties)
+				assert.Equal(t, ingested[i].test.SystemOut, streamed[i].test.SystemOut)
+				assert.Equal(t, ingested[i].test.SystemErr, streamed[i].test.SystemErr)
+				assert.Equal(t, ingested[i].test.Attempts, streamed[i].test.Attempts)
+				assert.Equal(t, ingested[i].test.Failures, streamed[i].test.Failures)
+			}
+
+			var expected Totals
+			for i := range suites {
+				if suites[i].Name != "" {
+					addTotals(&expected, &suites[i].Totals)
+				}
+			}
+			assert.Equal(t, expected, totals)
+		})
+	}
+}
+
+func TestStreamJUnitOutputLimit(t *testing.T) {
+	input := `<testsuites>
+	<testsuite name="Outer">
+		<properties><property name="java.version" value="17"/></properties>
+		<testcase name="chatty" classname="LogTest">
+			<system-out><![CDATA[0123456789abcdef]]></system-out>
+			<system-err>aaaaaaaaaéé</system-err>
+		</testcase>
+		<testsuite name="Inner">
+			<testcase name="broken" classname="InnerTest"><failure message="boom" type="AssertionError">trace</failure></testcase>
+		</testsuite>
+		<system-out>suite output which is long</system-out>
+	</testsuite>
+</testsuites>`
+
+	var (
+		tests  []streamedTest
+		suites []Suite
+		paths  []string
+	)
+	err := StreamJUnit(strings.NewReader(input), Options{MaxOutputBytes: 10}, StreamHandler{
+		Test: func(path SuitePath, test *Test) error {
+			tests = append(tests, streamedTest{path.String(), *test})
+			return nil
+		},
+		Suite: func(path SuitePath, suite *Suite) error {
+			suites = append(suites, *suite)
+			paths = append(paths, path.String())
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, tests, 2)
+	assert.Equal(t, "Outer", tests[0].path)
+	assert.Equal(t, "0123456789\n[truncated 6 bytes]", tests[0].test.SystemOut)
+	assert.Equal(t, "aaaaaaaaa\n[truncated 4 bytes]", tests[0].test.SystemErr)
+	assert.Equal(t, "Outer/Inner", tests[1].path)
+	assert.Equal(t, Result{Status: StatusFailed, Message: "boom", Type: "AssertionError", Desc: "trace"}, tests[1].test.Result)
+
+	assert.Equal(t, []string{"Outer/Inner", "Outer"}, paths)
+	assert.Equal(t, Totals{Tests: 1, Failed: 1}, suites[0].Totals)
+	assert.Equal(t, Totals{Tests: 2, Passed: 1, Failed: 1}, suites[1].Totals)
+	assert.Equal(t, map[string]string{"java.version": "17"}, suites[1].Properties)
+	assert.Equal(t, "suite outp\n[truncated 16 bytes]", suites[1].SystemOut)
+	assert.Empty(t, suites[1].Tests)
+}
+
+// TestStreamJUnitOutputLimitMarkup checks that output is limited without
+// breaking the references, CDATA sections, comments and tags within it.
+func TestStreamJUnitOutputLimitMarkup(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{`ab&amp;cdefghijkl`, "ab&cd\n[truncated 8 bytes]"},
+		{`<![CDATA[0123]]]]>tail`, "01\n[truncated 8 bytes]"},
+		{`abc<!-- x > y --><b title="1>2">def</b>ghi`, "abcde\n[truncated 4 bytes]"},
+		{`ab<?pi a > b ?>cd<![CDATA[<ef>]]>`, "abcd<\n[truncated 3 bytes]"},
+		{`ééé`, "éé\n[truncated 2 bytes]"},
+		{`<![CDATA[0]]>12<![CDATA[3]]>456`, "01\n[truncated 5 bytes]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			input := `<testsuite name="a"><testcase name="one"><system-out>` + test.output + `</system-out>` +
+				`<failure message="a > b">` + test.output + `</failure></testcase></testsuite>`
+			stream := func(limit int) Test {
+				var tests []Test
+				err := StreamJUnit(strings.NewReader(input), Options{MaxOutputBytes: limit}, StreamHandler{
+					Test: func(_ SuitePath, test *Test) error {
+						tests = append(tests, *test)
+						return nil
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, tests, 1)
+				return tests[0]
+			}
+
+			limit := 5
+			if strings.HasPrefix(test.output, "<![CDATA[") {
+				limit = 2
+			}
+			unlimited, limited := stream(0), stream(limit)
+			assert.Equal(t, test.expected, limited.SystemOut)
+			assert.Equal(t, truncateOutput(unlimited.SystemOut, limit), limited.SystemOut)
+
+			// The description of a failure is not limited.
+			assert.Equal(t, unlimited.Result.Desc, limited.Result.Desc)
+		})
+	}
+}
+
+// repeatReader yields the given number of copies of a byte.
+type repeatReader struct {
+	b byte
+	n int64
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = r.b
+	}
+	r.n -= int64(len(p))
+	return len(p), nil
+}
+
+// TestStreamJUnitOversizedOutput checks that a single huge run of text is
+// limited without being read into memory.
+func TestStreamJUnitOversizedOutput(t *testing.T) {
+	const size = 64 << 20
+	input := io.MultiReader(
+		strings.NewReader(`<testsuite name="a"><testcase name="chatty"><system-out><![CDATA[`),
+		&repeatReader{b: 'x', n: size},
+		strings.NewReader(`]]></system-out><system-err>`),
+		&repeatReader{b: 'y', n: size},
+		strings.NewReader(`</system-err></testcase><testcase name="broken"><failure/></testcase></testsuite>`),
+	)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var tests []Test
+	err := StreamJUnit(input, Options{MaxOutputBytes: 16}, StreamHandler{
+		Test: func(_ SuitePath, test *Test) error {
+			tests = append(tests, *test)
+			return nil
+		},
+	})
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err)
+
+	require.Len(t, tests, 2)
+	assert.Equal(t, fmt.Sprintf("xxxxxxxxxxxxxxxx\n[truncated %d bytes]", size-16), tests[0].SystemOut)
+	assert.Equal(t, fmt.Sprintf("yyyyyyyyyyyyyyyy\n[truncated %d bytes]", size-16), tests[0].SystemErr)
+	assert.Equal(t, Status(StatusFailed), tests[1].Result.Status)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(size/16))
+}
+
+func TestStreamJUnitTruncated(t *testing.T) {
+	input := `<testsuite name="a"><testcase name="one"/><testcase name="two">`
+
+	var names []string
+	err := StreamJUnit(strings.NewReader(input), Options{}, StreamHandler{
+		Test: func(_ SuitePath, test *Test) error {
+			names = append(names, test.Name)
+			return nil
+		},
+	})
+	assert.ErrorContains(t, err, "unexpected EOF")
+	assert.Equal(t, []string{"one"}, names)
+}
+
+func TestStreamFile(t *testing.T) {
+	suites, err := IngestFile("testdata/nunit3.xml")
+	require.NoError(t, err)
+
+	var count int
+	format, err := StreamFile("testdata/nunit3.xml", Options{MaxOutputBytes: 5}, StreamHandler{
+		Test: func(_ SuitePath, test *Test) error {
+			count++
+			assert.LessOrEqual(t, len(strings.Split(test.SystemOut, "\n")[0]), 5)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FormatNUnit, format)
+	assert.Equal(t, suites[0].Totals.Tests, count)
+}
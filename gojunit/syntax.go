@@ -0,0 +1,187 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetContext is the number of bytes on either side of the position of a
+// SyntaxError which are included in its snippet.
+const snippetContext = 40
+
+// SyntaxError is returned when a report is malformed, such as a report which
+// was truncated by a test runner that was killed while writing it.
+type SyntaxError struct {
+	// Offset is the number of bytes of the report which precede the error.
+	Offset int64
+
+	// Line and Column are the position of the error, counted from 1, and
+	// Snippet is the text of the line around it. They are only set once the
+	// error is located with Locate.
+	Line    int
+	Column  int
+	Snippet string
+
+	// Err is the error of the decoder.
+	Err error
+
+	// xml is set for the errors of XML decoders, whose offsets are those of
+	// the report once it is sanitized.
+	xml bool
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("offset %d: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Locate sets the line, column and snippet of the error from the given report,
+// which is read only up to shortly after the error. An error which is past the
+// end of the report, such as that of a truncated report, is located at its end.
+func (e *SyntaxError) Locate(reader io.Reader) error {
+	var (
+		buffered = bufio.NewReader(reader)
+		line     = 1
+		column   = 1
+		before   []byte
+		offset   int64
+	)
+
+	for ; offset < e.Offset; offset++ {
+		b, err := buffered.ReadByte()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if b == '\n' {
+			line++
+			column = 1
+			before = before[:0]
+			continue
+		}
+		if utf8.RuneStart(b) {
+			column++
+		}
+		before = append(before, b)
+		if len(before) > 2*snippetContext {
+			before = append(before[:0], before[len(before)-snippetContext:]...)
+		}
+	}
+	if len(before) > snippetContext {
+		before = before[len(before)-snippetContext:]
+	}
+
+	var after []byte
+	for len(after) < snippetContext {
+		b, err := buffered.ReadByte()
+		if err != nil || b == '\n' {
+			break
+		}
+		after = append(after, b)
+	}
+
+	e.Line = line
+	e.Column = column
+	e.Snippet = strings.TrimSpace(strings.ToValidUTF8(string(before)+string(after), ""))
+	return nil
+}
+
+// decodeJSON decodes a JSON document from the given reader into the given
+// value, and returns a SyntaxError if the document is malformed or does not
+// match the value.
+func decodeJSON(reader io.Reader, v interface{}) error {
+	counter := &countingReader{reader: reader}
+	if err := json.NewDecoder(counter).Decode(v); err != nil {
+		return jsonSyntaxError(err, 0, counter.count)
+	}
+	return nil
+}
+
+// jsonSyntaxError returns the given error of a JSON decoder as a SyntaxError.
+// The decoder started at the given offset of the report, and a truncated
+// document is reported at the given end offset.
+func jsonSyntaxError(err error, start, end int64) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &SyntaxError{Offset: start + syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &SyntaxError{Offset: start + typeErr.Offset, Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &SyntaxError{Offset: end, Err: err}
+	default:
+		return err
+	}
+}
+
+// xmlSyntaxError returns the given error of an XML decoder as a SyntaxError at
+// the given offset, unless it was returned by the underlying reader.
+func xmlSyntaxError(err error, offset int64) error {
+	var syntaxErr *xml.SyntaxError
+	if !errors.As(err, &syntaxErr) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return &SyntaxError{Offset: offset, Err: err, xml: true}
+}
+
+// locateError locates a SyntaxError which was returned when parsing the given
+// file.
+func locateError(filename string, err error) {
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 0 {
+		return
+	}
+
+	file, openErr := OpenFile(filename)
+	if openErr != nil {
+		return
+	}
+	defer file.Close()
+
+	// The report is read as it was parsed, so that the offset matches.
+	reader, decodeErr := decodeCharset(file)
+	if decodeErr != nil {
+		return
+	}
+	if syntaxErr.xml {
+		reader = sanitizeXML(reader)
+	}
+	_ = syntaxErr.Locate(reader)
+}
+
+// countingReader counts the bytes which are read from a reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntaxErrorLocate(t *testing.T) {
+	report := "<testsuite>\n  <testcase name=\"é\" <oops/>\n</testsuite>\n"
+
+	err := &SyntaxError{Offset: int64(strings.Index(report, "<oops"))}
+	require.NoError(t, err.Locate(strings.NewReader(report)))
+	assert.Equal(t, 2, err.Line)
+	assert.Equal(t, 22, err.Column)
+	assert.Equal(t, `<testcase name="é" <oops/>`, err.Snippet)
+
+	// An error past the end of the report is located at its end.
+	err = &SyntaxError{Offset: 1000}
+	require.NoError(t, err.Locate(strings.NewReader("<testsuite>\n  <testca")))
+	assert.Equal(t, 2, err.Line)
+	assert.Equal(t, 10, err.Column)
+	assert.Equal(t, "<testca", err.Snippet)
+}
+
+func TestIngestFileSyntaxError(t *testing.T) {
+	tests := []struct {
+		fixture string
+		format  Format
+		length  int
+		line    int
+	}{
+		{"testdata/surefire.xml", FormatJUnit, 700, 10},
+		{"testdata/nunit3.xml", FormatNUnit, 500, 4},
+		{"testdata/jest.json", FormatJest, 300, 13},
+		{"testdata/go-test.json", FormatGoTestJSON, 200, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(test.fixture)
+			require.NoError(t, err)
+			filename := filepath.Join(t.TempDir(), filepath.Base(test.fixture))
+			require.NoError(t, os.WriteFile(filename, data[:test.length], 0o644))
+
+			for name, ingest := range map[string]func() error{
+				"ingest": func() error {
+					_, _, err := IngestFileOptions(filename, Options{Format: test.format})
+					return err
+				},
+				"stream": func() error {
+					_, err := StreamFile(filename, Options{Format: test.format}, StreamHandler{
+						Test: func(SuitePath, *Test) error { return nil },
+					})
+					return err
+				},
+			} {
+				err := ingest()
+				var syntaxErr *SyntaxError
+				require.ErrorAs(t, err, &syntaxErr, name)
+				assert.Equal(t, test.line, syntaxErr.Line, name)
+				assert.NotZero(t, syntaxErr.Column, name)
+				assert.NotEmpty(t, syntaxErr.Snippet, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	tapTestPoint = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+	tapPlan      = regexp.MustCompile(`^1\.\.(\d+)\s*(?:#\s*(.*))?$`)
+)
+
+// tapIndent is the indentation of TAP version 14 subtests.
+const tapIndent = "    "
+
+// tapParser holds the lines of a TAP document while it is being parsed.
+type tapParser struct {
+	lines  []string
+	pos    int
+	bailed bool
+}
+
+// IngestTAP will parse the given Test Anything Protocol (version 13 or 14)
+// document and return a slice with a single suite containing its tests.
+// Indented version 14 subtests are returned as nested suites, and a "Bail out!"
+// line, or a mismatch between the plan and the number of test points, is
+// reported as an error test.
+func IngestTAP(reader io.Reader) ([]Suite, error) {
+	var (
+		parser   tapParser
+		buffered = bufio.NewReader(reader)
+	)
+
+	for {
+		line, err := buffered.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if line != "" {
+			parser.lines = append(parser.lines, strings.TrimRight(line, "\r\n"))
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	return []Suite{parser.suite("", "")}, nil
+}
+
+// suite parses lines at the given indentation into a suite, until the end of
+// the document or a line with less indentation is reached.
+func (p *tapParser) suite(name, indent string) Suite {
+	var (
+		suite   = Suite{Name: name}
+		output  strings.Builder
+		planned = -1
+		points  int
+		subtest *Suite
+		subName string
+	)
+
+	for p.pos < len(p.lines) && !p.bailed {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			p.pos++
+			continue
+		}
+		if !strings.HasPrefix(line, indent) {
+			break
+		}
+
+		body := line[len(indent):]
+		if strings.HasPrefix(body, tapIndent) {
+			nested := p.suite(subName, indent+tapIndent)
+			if subtest != nil {
+				suite.Suites = append(suite.Suites, *subtest)
+			}
+			subtest, subName = &nested, ""
+			continue
+		}
+		p.pos++
+
+		switch {
+		case tapTestPoint.MatchString(body):
+			points++
+			test := p.testPoint(body, indent, suite.Name)
+			if subtest == nil {
+				suite.Tests = append(suite.Tests, test)
+				continue
+			}
+
+			// The test point following a subtest reports its result, which
+			// is only kept when the failure is not already accounted for by
+			// the tests of the subtest, such as a plan failure.
+			if subtest.Name == "" {
+				subtest.Name = test.Name
+			}
+			subtest.Aggregate()
+			if (test.Result.Status == StatusFailed || test.Result.Status == StatusError) &&
+				subtest.Totals.Failed == 0 && subtest.Totals.Error == 0 {
+				subtest.Tests = append(subtest.Tests, test)
+			}
+			suite.Suites = append(suite.Suites, *subtest)
+			subtest = nil
+		case tapPlan.MatchString(body):
+			planned, _ = strconv.Atoi(tapPlan.FindStringSubmatch(body)[1])
+		case strings.HasPrefix(body, "Bail out!"):
+			p.bailed = true
+			suite.Tests = append(suite.Tests, Test{
+				Name:      "Bail out!",
+				Classname: suite.Name,
+				Result: Result{
+					Status:  StatusError,
+					Message: strings.TrimSpace(strings.TrimPrefix(body, "Bail out!")),
+				},
+			})
+		case strings.HasPrefix(body, "# Subtest"):
+			subName = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(body, "# Subtest"), ":"))
+		case strings.HasPrefix(body, "TAP version"), strings.HasPrefix(body, "pragma "):
+		default:
+			output.WriteString(body + "\n")
+		}
+	}
+
+	if subtest != nil {
+		suite.Suites = append(suite.Suites, *subtest)
+	}
+
+	if planned >= 0 && planned != points && !p.bailed {
+		suite.Tests = append(suite.Tests, Test{
+			Name:      "plan",
+			Classname: suite.Name,
+			Result: Result{
+				Status:  StatusError,
+				Message: fmt.Sprintf("planned %d tests but ran %d", planned, points),
+			},
+		})
+	}
+
+	suite.SystemOut = output.String()
+	suite.Aggregate()
+	return suite
+}
+
+// testPoint parses an "ok" or "not ok" line, along with the YAML diagnostic
+// block which may follow it.
+func (p *tapParser) testPoint(line, indent, classname string) Test {
+	var (
+		match                  = tapTestPoint.FindStringSubmatch(line)
+		description, directive = splitTAPDirective(match[3])
+		test                   = Test{
+			Name:      description,
+			Classname: classname,
+			Result:    Result{Status: StatusPassed},
+		}
+	)
+
+	if test.Name == "" {
+		test.Name = "test " + match[2]
+	}
+
+	if match[1] != "" {
+		test.Result.Status = StatusFailed
+	}
+
+	switch keyword, reason := splitTAPKeyword(directive); keyword {
+	case "SKIP", "SKIPPED":
+		test.Result.Status = StatusSkipped
+		test.Result.Message = reason
+	case "TODO":
+		// Failing TODO tests are expected to fail, and are not failures.
+		if test.Result.Status == StatusFailed {
+			test.Result.Status = StatusSkipped
+		}
+		test.Result.Message = reason
+	}
+
+	if yamlBlock, ok := p.diagnostics(indent + "  "); ok {
+		test.Result.Desc = yamlBlock
+
+		var diagnostics map[string]interface{}
+		if err := yaml.Unmarshal([]byte(yamlBlock), &diagnostics); err == nil {
+			if message, ok := diagnostics["message"].(string); ok {
+				test.Result.Message = message
+			}
+			switch ms := diagnostics["duration_ms"].(type) {
+			case int:
+				test.DurationMs = int64(ms)
+			case float64:
+				test.DurationMs = int64(ms)
+			}
+		}
+	}
+
+	return test
+}
+
+// diagnostics consumes the YAML diagnostic block at the given indentation, if
+// the next line starts one, and returns its contents.
+func (p *tapParser) diagnostics(indent string) (string, bool) {
+	if p.pos >= len(p.lines) || strings.TrimRight(p.lines[p.pos], " ") != indent+"---" {
+		return "", false
+	}
+	p.pos++
+
+	var block strings.Builder
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		p.pos++
+		if strings.TrimRight(line, " ") == indent+"..." {
+			break
+		}
+		block.WriteString(strings.TrimPrefix(line, indent) + "\n")
+	}
+
+	return block.String(), true
+}
+
+// splitTAPDirective splits the description of a test point from its "# SKIP"
+// or "# TODO" directive. An escaped "\#" is part of the description.
+func splitTAPDirective(s string) (string, string) {
+	var description strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '#' || s[i+1] == '\\'):
+			description.WriteByte(s[i+1])
+			i++
+		case s[i] == '#':
+			return strings.TrimSpace(description.String()), strings.TrimSpace(s[i+1:])
+		default:
+			description.WriteByte(s[i])
+		}
+	}
+	return strings.TrimSpace(description.String()), ""
+}
+
+// splitTAPKeyword splits a directive such as "SKIP: no database" into its
+// upper cased keyword and reason.
+func splitTAPKeyword(directive string) (string, string) {
+	end := strings.IndexFunc(directive, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(directive)
+	}
+	reason := strings.TrimPrefix(strings.TrimSpace(directive[end:]), ":")
+	return strings.ToUpper(directive[:end]), strings.TrimSpace(reason)
+}
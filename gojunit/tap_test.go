@@ -0,0 +1,103 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestTAP(t *testing.T) { //nolint:funlen
+	tests := []struct {
+		title    string
+		filename string
+		check    func(*testing.T, []Suite)
+	}{
+		{
+			title:    "tap version 14",
+			filename: "testdata/tap14.tap",
+			check: func(t *testing.T, suites []Suite) {
+				require.Len(t, suites, 1)
+				suite := suites[0]
+				assert.Equal(t, "# running the calculator tests\n", suite.SystemOut)
+				require.Len(t, suite.Tests, 4)
+				require.Len(t, suite.Suites, 2)
+
+				assert.Equal(t, "add two numbers", suite.Tests[0].Name)
+				assert.Equal(t, Status(StatusPassed), suite.Tests[0].Result.Status)
+
+				failed := suite.Tests[1]
+				assert.Equal(t, "divide by zero", failed.Name)
+				assert.Equal(t, Status(StatusFailed), failed.Result.Status)
+				assert.Equal(t, "expected an error", failed.Result.Message)
+				assert.Contains(t, failed.Result.Desc, "got: Infinity")
+				assert.Equal(t, int64(250), failed.DurationMs)
+
+				assert.Equal(t, Status(StatusSkipped), suite.Tests[2].Result.Status)
+				assert.Equal(t, "not implemented yet", suite.Tests[2].Result.Message)
+
+				todo := suite.Tests[3]
+				assert.Equal(t, "parse # comments", todo.Name)
+				assert.Equal(t, Status(StatusSkipped), todo.Result.Status)
+				assert.Equal(t, "parser does not support comments", todo.Result.Message)
+
+				parser := suite.Suites[0]
+				assert.Equal(t, "parser", parser.Name)
+				require.Len(t, parser.Tests, 2)
+				assert.Equal(t, "parser", parser.Tests[1].Classname)
+				assert.Equal(t, "rounding error", parser.Tests[1].Result.Message)
+
+				// The subtest passes all of its tests but misses one from its
+				// plan, so the failing closing test point is not counted twice.
+				formatter := suite.Suites[1]
+				assert.Equal(t, "formatter", formatter.Name)
+				require.Len(t, formatter.Tests, 3)
+				assert.Equal(t, "plan", formatter.Tests[2].Name)
+				assert.Equal(t, "planned 3 tests but ran 2", formatter.Tests[2].Result.Message)
+
+				expectedTotals := Totals{
+					Tests:      9,
+					Passed:     4,
+					Skipped:    2,
+					Failed:     2,
+					Error:      1,
+					DurationMs: 250,
+				}
+				assert.Equal(t, expectedTotals, suite.Totals)
+			},
+		},
+		{
+			title:    "tap version 13 bail out",
+			filename: "testdata/tap13-bail.tap",
+			check: func(t *testing.T, suites []Suite) {
+				require.Len(t, suites, 1)
+				require.Len(t, suites[0].Tests, 3)
+
+				bail := suites[0].Tests[2]
+				assert.Equal(t, "Bail out!", bail.Name)
+				assert.Equal(t, Status(StatusError), bail.Result.Status)
+				assert.Equal(t, "Couldn't connect to database.", bail.Result.Message)
+			},
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("#%d - %s", index+1, test.title)
+
+		t.Run(name, func(t *testing.T) {
+			file, err := os.Open(test.filename)
+			require.NoError(t, err)
+			defer file.Close()
+
+			suites, err := IngestTAP(file)
+			require.NoError(t, err)
+			test.check(t, suites)
+		})
+	}
+}
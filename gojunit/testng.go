@@ -0,0 +1,170 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"strconv"
+	"strings"
+)
+
+// findTestNGSuites ingests the "suite" tags of a "testng-results" document.
+func findTestNGSuites(root xmlNode, suites chan Suite) { //nolint:gocritic
+	for _, node := range root.Nodes {
+		if node.XMLName.Local == "suite" {
+			suites <- ingestTestNGSuite(node)
+		}
+	}
+}
+
+// ingestTestNGSuite ingests a TestNG "suite" tag, with each "test" tag as a
+// nested suite, and each "class" tag as a suite nested within its test.
+func ingestTestNGSuite(root xmlNode) Suite { //nolint:gocritic
+	suite := Suite{
+		Name:       root.Attr("name"),
+		Properties: root.Attrs,
+	}
+
+	groups := ingestTestNGGroups(root)
+
+	for _, node := range root.Nodes {
+		if node.XMLName.Local != "test" {
+			continue
+		}
+
+		test := Suite{
+			Name:       node.Attr("name"),
+			Package:    suite.Name,
+			Properties: node.Attrs,
+		}
+		for _, class := range node.Nodes {
+			if class.XMLName.Local == "class" {
+				test.Suites = append(test.Suites, ingestTestNGClass(class, groups))
+			}
+		}
+		test.Aggregate()
+
+		suite.Suites = append(suite.Suites, test)
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+// ingestTestNGGroups returns the groups of each method in the given suite,
+// keyed by the class and method name.
+func ingestTestNGGroups(root xmlNode) map[string][]string { //nolint:gocritic
+	groups := make(map[string][]string)
+
+	if node := root.Child("groups"); node != nil {
+		for _, group := range node.Nodes {
+			for _, method := range group.Nodes {
+				key := method.Attr("class") + "." + method.Attr("name")
+				groups[key] = append(groups[key], group.Attr("name"))
+			}
+		}
+	}
+
+	return groups
+}
+
+func ingestTestNGClass(root xmlNode, groups map[string][]string) Suite { //nolint:gocritic
+	suite := Suite{
+		Name: root.Attr("name"),
+	}
+
+	for _, node := range root.Nodes {
+		if node.XMLName.Local != "test-method" {
+			continue
+		}
+
+		// Configuration methods, such as @BeforeClass, are only reported
+		// when they fail, as they would otherwise inflate the test count.
+		if node.Attr("is-config") == "true" && node.Attr("status") != "FAIL" {
+			continue
+		}
+
+		suite.Tests = append(suite.Tests, ingestTestNGMethod(node, suite.Name, groups))
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+func ingestTestNGMethod(root xmlNode, classname string, groups map[string][]string) Test { //nolint:gocritic
+	test := Test{
+		Name:       root.Attr("name"),
+		Classname:  classname,
+		Result:     Result{Status: testngStatus(root)},
+		Properties: make(map[string]string, len(root.Attrs)+2), //nolint:gomnd
+	}
+
+	if ms, err := strconv.ParseInt(root.Attr("duration-ms"), 10, 64); err == nil {
+		test.DurationMs = ms
+	}
+
+	for name, value := range root.Attrs {
+		test.Properties[name] = value
+	}
+	if params := ingestTestNGParams(root); params != "" {
+		test.Properties[ParamsProperty] = params
+	}
+	if names := groups[classname+"."+test.Name]; len(names) != 0 {
+		test.Properties["groups"] = strings.Join(names, ",")
+	}
+
+	if exception := root.Child("exception"); exception != nil {
+		test.Result.Type = exception.Attr("class")
+		test.Result.Message = testngContent(*exception, "message")
+		test.Result.Desc = testngContent(*exception, "full-stacktrace")
+	}
+
+	if output := root.Child("reporter-output"); output != nil {
+		var lines []string
+		for _, line := range output.Nodes {
+			lines = append(lines, strings.TrimSpace(string(line.Content)))
+		}
+		test.SystemOut = strings.Join(lines, "\n")
+	}
+
+	return test
+}
+
+// ingestTestNGParams returns the values of the data provider parameters of the
+// given method, joined with a comma.
+func ingestTestNGParams(root xmlNode) string { //nolint:gocritic
+	node := root.Child("params")
+	if node == nil {
+		return ""
+	}
+
+	values := make([]string, 0, len(node.Nodes))
+	for _, param := range node.Nodes {
+		values = append(values, testngContent(param, "value"))
+	}
+	return strings.Join(values, ",")
+}
+
+// testngContent returns the content of the named child node without the
+// indentation that TestNG adds around its CDATA sections.
+func testngContent(root xmlNode, name string) string { //nolint:gocritic
+	return strings.TrimSpace(root.ChildContent(name))
+}
+
+// testngStatus maps the status of a TestNG method to a status. Failing
+// configuration methods are reported as errors, as they prevent the tests
+// which depend on them from running.
+func testngStatus(root xmlNode) Status { //nolint:gocritic
+	switch root.Attr("status") {
+	case "PASS":
+		return StatusPassed
+	case "FAIL":
+		if root.Attr("is-config") == "true" {
+			return StatusError
+		}
+		return StatusFailed
+	default:
+		return StatusSkipped
+	}
+}
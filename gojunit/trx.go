@@ -0,0 +1,165 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// findTRXSuites ingests a Visual Studio "TestRun" document. TRX files record
+// results separately from the test definitions, so each "UnitTestResult" is
+// joined with its "UnitTest" definition by test id, and the tests are grouped
+// into one suite per class in order of first appearance.
+func findTRXSuites(root xmlNode, suites chan Suite) { //nolint:gocritic
+	definitions := make(map[string]xmlNode)
+	if node := root.Child("TestDefinitions"); node != nil {
+		for _, definition := range node.Nodes {
+			definitions[definition.Attr("id")] = definition
+		}
+	}
+
+	var (
+		classes []string
+		grouped = make(map[string][]Test)
+	)
+
+	if node := root.Child("Results"); node != nil {
+		for _, result := range node.Nodes {
+			for _, test := range ingestTRXResult(result, definitions) {
+				if _, ok := grouped[test.Classname]; !ok {
+					classes = append(classes, test.Classname)
+				}
+				grouped[test.Classname] = append(grouped[test.Classname], test)
+			}
+		}
+	}
+
+	for _, class := range classes {
+		suite := Suite{
+			Name:  class,
+			Tests: grouped[class],
+		}
+		suite.Aggregate()
+		suites <- suite
+	}
+}
+
+// ingestTRXResult returns the tests recorded by a single result. Data driven
+// tests record each data row as an inner result of the parent result, in which
+// case only the inner results are returned.
+func ingestTRXResult(root xmlNode, definitions map[string]xmlNode) []Test { //nolint:gocritic
+	if inner := root.Child("InnerResults"); inner != nil && len(inner.Nodes) != 0 {
+		var tests []Test
+		for _, node := range inner.Nodes {
+			tests = append(tests, ingestTRXResult(node, definitions)...)
+		}
+		return tests
+	}
+
+	var (
+		definition = definitions[root.Attr("testId")]
+		method     = definition.Child("TestMethod")
+		name       = root.Attr("testName")
+		classname  string
+	)
+
+	if method != nil {
+		// Older TRX files use an assembly qualified class name.
+		classname, _, _ = strings.Cut(method.Attr("className"), ",")
+		if name == "" {
+			name = method.Attr("name")
+		}
+	}
+	// Some adapters, such as xUnit, report fully qualified test names.
+	if classname != "" {
+		name = strings.TrimPrefix(name, classname+".")
+	}
+
+	test := Test{
+		Name:       name,
+		Classname:  classname,
+		DurationMs: trxDuration(root.Attr("duration")).Milliseconds(),
+		Result:     Result{Status: trxStatus(root.Attr("outcome"))},
+		Properties: ingestTRXProperties(root, definition),
+	}
+
+	if output := root.Child("Output"); output != nil {
+		test.SystemOut = output.ChildContent("StdOut")
+		test.SystemErr = output.ChildContent("StdErr")
+		if info := output.Child("ErrorInfo"); info != nil {
+			test.Result.Message = info.ChildContent("Message")
+			test.Result.Desc = info.ChildContent("StackTrace")
+		}
+	}
+
+	return []Test{test}
+}
+
+// ingestTRXProperties merges the attributes of the given result with the
+// categories of its test definition.
+func ingestTRXProperties(result, definition xmlNode) map[string]string { //nolint:gocritic
+	props := make(map[string]string, len(result.Attrs)+1)
+	for name, value := range result.Attrs {
+		props[name] = value
+	}
+
+	var categories []string
+	if node := definition.Child("TestCategory"); node != nil {
+		for _, item := range node.Nodes {
+			categories = append(categories, item.Attr("TestCategory"))
+		}
+	}
+	if len(categories) != 0 {
+		props["TestCategory"] = strings.Join(categories, ",")
+	}
+
+	return props
+}
+
+// trxStatus maps a TRX outcome to a status.
+func trxStatus(outcome string) Status {
+	switch outcome {
+	case "Passed", "PassedButRunAborted", "Warning", "Completed":
+		return StatusPassed
+	case "Failed":
+		return StatusFailed
+	case "Error", "Timeout", "Aborted", "Disconnected", "NotRunnable":
+		return StatusError
+	default:
+		// NotExecuted, Inconclusive, Pending and InProgress tests.
+		return StatusSkipped
+	}
+}
+
+// trxDuration parses a TRX duration, which is formatted as a .NET TimeSpan
+// such as "00:01:02.5000000" or "1.00:00:00".
+func trxDuration(s string) time.Duration {
+	var days time.Duration
+	if d, rest, ok := strings.Cut(s, "."); ok && strings.Contains(rest, ":") {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0
+		}
+		days, s = time.Duration(n)*24*time.Hour, rest
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 { //nolint:gomnd
+		return 0
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0
+	}
+
+	return days + time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + duration(parts[2])
+}
@@ -22,6 +22,11 @@ const (
 	// StatusError represents an unexpected violation of the test itself, such as
 	// an uncaught exception.
 	StatusError = "error"
+
+	// StatusFlaky represents a test which passed when it was rerun after it
+	// failed. It is derived by Test.DerivedStatus, and is never the status of a
+	// result.
+	StatusFlaky = "flaky"
 )
 
 type (
@@ -154,4 +159,45 @@ type Test struct {
 	// SystemErr is textual error output for the test case. Usually output that is
 	// written to stderr.
 	SystemErr string `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+
+	// Attempts is an ordered collection of the earlier attempts of a test
+	// which was retried. The final attempt is the test itself.
+	Attempts []Attempt `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+
+	// Failures is an ordered collection of every failure and error of a test
+	// which reported more than one, of which Result is the last.
+	Failures []Result `json:"failures,omitempty" yaml:"failures,omitempty"`
+}
+
+// DerivedStatus returns the status of the test, or StatusFlaky for a test which
+// passed after an earlier attempt of it failed, or which its report marked as
+// flaky.
+func (t *Test) DerivedStatus() Status {
+	if t.Result.Status != StatusPassed {
+		return t.Result.Status
+	}
+	if t.Properties["flaky"] == "true" {
+		return StatusFlaky
+	}
+	for _, attempt := range t.Attempts {
+		if attempt.Result.Status == StatusFailed || attempt.Result.Status == StatusError {
+			return StatusFlaky
+		}
+	}
+	return t.Result.Status
+}
+
+// Attempt represents the results of a single attempt of a retried test.
+type Attempt struct {
+	// DurationMs is the time taken by the attempt in milliseconds.
+	DurationMs int64 `json:"duration_ms" yaml:"duration"`
+
+	// Result contains information related to the status of the attempt.
+	Result Result `json:"Result" yaml:"Result"`
+
+	// SystemOut is textual output of the attempt.
+	SystemOut string `json:"stdout,omitempty" yaml:"stdout,omitempty"`
+
+	// SystemErr is textual error output of the attempt.
+	SystemErr string `json:"stderr,omitempty" yaml:"stderr,omitempty"`
 }
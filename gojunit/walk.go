@@ -0,0 +1,64 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import "strings"
+
+// SuitePathSeparator separates the names of nested suites in a SuitePath.
+const SuitePathSeparator = "/"
+
+// SuitePath is the names of the suites leading to a test, starting with the
+// outermost suite. Suites without a name are omitted.
+type SuitePath []string
+
+// String returns the names of the suites joined with a slash, such as
+// "Outer/Inner".
+func (p SuitePath) String() string {
+	return strings.Join(p, SuitePathSeparator)
+}
+
+// WalkTests calls fn for every test of the given suites and of their nested
+// suites, in order, along with the path of the suite containing the test. The
+// tests of a suite are visited before those of its nested suites.
+func WalkTests(suites []Suite, fn func(path SuitePath, test *Test)) {
+	handler := StreamHandler{
+		Test: func(path SuitePath, test *Test) error {
+			fn(path, test)
+			return nil
+		},
+	}
+	for i := range suites {
+		_ = walkSuite(&suites[i], nil, handler)
+	}
+}
+
+// walkSuite passes the tests of the given suite to the handler, followed by
+// those of its nested suites, and then the suite itself without its tests and
+// nested suites, which is the order in which StreamJUnit passes them.
+func walkSuite(suite *Suite, path SuitePath, handler StreamHandler) error {
+	if suite.Name != "" {
+		// The capacity is limited so that sibling suites never share, and
+		// overwrite, the backing array of a path retained by the handler.
+		path = append(path[:len(path):len(path)], suite.Name)
+	}
+
+	for i := range suite.Tests {
+		if err := handler.Test(path, &suite.Tests[i]); err != nil {
+			return err
+		}
+	}
+	for i := range suite.Suites {
+		if err := walkSuite(&suite.Suites[i], path, handler); err != nil {
+			return err
+		}
+	}
+
+	if handler.Suite == nil {
+		return nil
+	}
+	shallow := *suite
+	shallow.Tests, shallow.Suites = nil, nil
+	return handler.Suite(path, &shallow)
+}
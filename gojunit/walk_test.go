@@ -0,0 +1,70 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkTests(t *testing.T) {
+	suites := []Suite{
+		{
+			Name:  "Outer",
+			Tests: []Test{{Name: "a"}},
+			Suites: []Suite{
+				{
+					Name:   "Inner",
+					Tests:  []Test{{Name: "b"}, {Name: "c"}},
+					Suites: []Suite{{Name: "Deepest", Tests: []Test{{Name: "d"}}}},
+				},
+				{
+					Tests: []Test{{Name: "e"}},
+				},
+				{
+					Name:  "Sibling",
+					Tests: []Test{{Name: "f"}},
+				},
+			},
+		},
+		{
+			Tests: []Test{{Name: "g"}},
+		},
+	}
+
+	var (
+		names []string
+		paths []SuitePath
+	)
+	WalkTests(suites, func(path SuitePath, test *Test) {
+		names = append(names, test.Name)
+		paths = append(paths, path)
+	})
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f", "g"}, names)
+
+	// Paths are retained after the walk, so they must not share storage.
+	expected := []string{"Outer", "Outer/Inner", "Outer/Inner", "Outer/Inner/Deepest", "Outer", "Outer/Sibling", ""}
+	require.Len(t, paths, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i], paths[i].String())
+	}
+}
+
+func TestWalkTestsNUnit(t *testing.T) {
+	suites, err := IngestFile("testdata/nunit3.xml")
+	require.NoError(t, err)
+
+	var total int
+	WalkTests(suites, func(path SuitePath, test *Test) {
+		total++
+		if test.Name == "Add" {
+			assert.Equal(t, "Calculator.Tests.dll/Calculator/CalculatorTests", path.String())
+		}
+	})
+	assert.Equal(t, suites[0].Totals.Tests, total)
+}
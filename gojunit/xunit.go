@@ -0,0 +1,142 @@
+// Copyright 2022 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package gojunit
+
+import "strings"
+
+// ingestXUnitAssembly ingests an xUnit.net v2 "assembly" tag. Each "collection"
+// is ingested as a nested suite, and any assembly level errors, such as a
+// failing fixture cleanup, are reported as error tests of the assembly so they
+// are not lost.
+func ingestXUnitAssembly(root xmlNode) Suite { //nolint:gocritic
+	suite := Suite{
+		Name:       root.Attr("name"),
+		Properties: root.Attrs,
+	}
+
+	for _, node := range root.Nodes {
+		switch node.XMLName.Local {
+		case "collection":
+			suite.Suites = append(suite.Suites, ingestXUnitCollection(node))
+		case "errors":
+			for _, err := range node.Nodes {
+				suite.Tests = append(suite.Tests, ingestXUnitError(err))
+			}
+		}
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+func ingestXUnitCollection(root xmlNode) Suite { //nolint:gocritic
+	suite := Suite{
+		Name:       root.Attr("name"),
+		Properties: root.Attrs,
+	}
+
+	for _, node := range root.Nodes {
+		if node.XMLName.Local == "test" {
+			suite.Tests = append(suite.Tests, ingestXUnitTest(node))
+		}
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+func ingestXUnitTest(root xmlNode) Test { //nolint:gocritic
+	var (
+		classname = root.Attr("type")
+		name      = root.Attr("name")
+	)
+
+	// Theories include their arguments in the fully qualified test name,
+	// which is preferred over the method name so that each data row is
+	// reported as a distinct test.
+	if trimmed := strings.TrimPrefix(name, classname+"."); classname != "" && trimmed != name {
+		name = trimmed
+	} else if method := root.Attr("method"); method != "" {
+		name = method
+	}
+
+	test := Test{
+		Name:       name,
+		Classname:  classname,
+		DurationMs: duration(root.Attr("time")).Milliseconds(),
+		Result:     Result{Status: xunitStatus(root.Attr("result"))},
+		Properties: ingestXUnitTraits(root),
+		SystemOut:  root.ChildContent("output"),
+	}
+
+	if failure := root.Child("failure"); failure != nil {
+		test.Result.Type = failure.Attr("exception-type")
+		test.Result.Message = failure.ChildContent("message")
+		test.Result.Desc = failure.ChildContent("stack-trace")
+	} else if test.Result.Status == StatusSkipped {
+		test.Result.Message = root.ChildContent("reason")
+	}
+
+	return test
+}
+
+// ingestXUnitTraits merges the attributes of the given test with its traits.
+// Traits which are declared more than once are joined with a comma.
+func ingestXUnitTraits(root xmlNode) map[string]string { //nolint:gocritic
+	props := make(map[string]string, len(root.Attrs))
+	for name, value := range root.Attrs {
+		props[name] = value
+	}
+
+	if traits := root.Child("traits"); traits != nil {
+		seen := make(map[string]bool)
+		for _, node := range traits.Nodes {
+			name, value := node.Attr("name"), node.Attr("value")
+			if seen[name] {
+				value = props[name] + "," + value
+			}
+			props[name] = value
+			seen[name] = true
+		}
+	}
+
+	return props
+}
+
+// ingestXUnitError converts an assembly level error, such as a failing class
+// fixture or collection cleanup, into an error test.
+func ingestXUnitError(root xmlNode) Test { //nolint:gocritic
+	name := root.Attr("name")
+	if name == "" {
+		name = root.Attr("type")
+	}
+
+	test := Test{
+		Name:       name,
+		Result:     Result{Status: StatusError},
+		Properties: root.Attrs,
+	}
+
+	if failure := root.Child("failure"); failure != nil {
+		test.Result.Type = failure.Attr("exception-type")
+		test.Result.Message = failure.ChildContent("message")
+		test.Result.Desc = failure.ChildContent("stack-trace")
+	}
+
+	return test
+}
+
+// xunitStatus maps an xUnit.net v2 test result to a status.
+func xunitStatus(result string) Status {
+	switch result {
+	case "Pass":
+		return StatusPassed
+	case "Fail":
+		return StatusFailed
+	default:
+		// Skip and NotRun tests.
+		return StatusSkipped
+	}
+}
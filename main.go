@@ -7,6 +7,10 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+// appName is the name of the plugin, which is also reported as the tool of the
+// CTRF report it writes.
+const appName = "harness-parse-test-reports"
+
 const (
 	globSetting           = "test_globs"
 	globEnv               = "PLUGIN_TEST_GLOBS"
@@ -14,13 +18,55 @@ const (
 	quarantineFileEnv     = "PLUGIN_QUARANTINE_FILE"
 	quarantineSetting     = "fail_on_quarantine"
 	quarantineEnv         = "PLUGIN_FAIL_ON_QUARANTINE"
+	reportFormatSetting   = "report_format"
+	reportFormatEnv       = "PLUGIN_REPORT_FORMAT"
+	pendingStatusSetting  = "cucumber_pending_status"
+	pendingStatusEnv      = "PLUGIN_CUCUMBER_PENDING_STATUS"
+	ctrfOutputSetting     = "ctrf_output"
+	ctrfOutputEnv         = "PLUGIN_CTRF_OUTPUT"
+	maxOutputBytesSetting = "max_output_bytes"
+	maxOutputBytesEnv     = "PLUGIN_MAX_OUTPUT_BYTES"
+	parseErrorSetting     = "fail_on_parse_error"
+	parseErrorEnv         = "PLUGIN_FAIL_ON_PARSE_ERROR"
+	recoverSetting        = "recover_truncated_reports"
+	recoverEnv            = "PLUGIN_RECOVER_TRUNCATED_REPORTS"
+	retryPolicySetting    = "retry_policy"
+	retryPolicyEnv        = "PLUGIN_RETRY_POLICY"
+	workersSetting        = "workers"
+	workersEnv            = "PLUGIN_WORKERS"
+	debugSetting          = "debug"
+	debugEnv              = "PLUGIN_DEBUG"
+	fromStdinSetting      = "from_stdin"
+	fromStdinEnv          = "PLUGIN_FROM_STDIN"
+	auditJSONSetting      = "quarantine_audit_json"
+	auditJSONEnv          = "PLUGIN_QUARANTINE_AUDIT_JSON"
+	auditMarkdownSetting  = "quarantine_audit_markdown"
+	auditMarkdownEnv      = "PLUGIN_QUARANTINE_AUDIT_MARKDOWN"
+	failOnStaleSetting    = "fail_on_stale_quarantine"
+	failOnStaleEnv        = "PLUGIN_FAIL_ON_STALE_QUARANTINE"
+	prunedFileSetting     = "pruned_quarantine_file"
+	prunedFileEnv         = "PLUGIN_PRUNED_QUARANTINE_FILE"
 )
 
 func main() {
 	app := &cli.App{
-		Name:   "harness-parse-test-reports",
+		Name:   appName,
 		Usage:  "Harness plugin to parse test reports",
 		Action: run,
+		Commands: []*cli.Command{
+			{
+				Name:  "quarantine",
+				Usage: "Manage quarantine files",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "validate",
+						Usage:     "Validate a quarantine file",
+						ArgsUsage: "<file>",
+						Action:    validateQuarantine,
+					},
+				},
+			},
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "test_globs",
@@ -34,6 +80,63 @@ func main() {
 				Name:    "fail_on_quarantine",
 				EnvVars: []string{"PLUGIN_FAIL_ON_QUARANTINE"},
 			},
+			&cli.StringFlag{
+				Name:    "report_format",
+				EnvVars: []string{"PLUGIN_REPORT_FORMAT"},
+			},
+			&cli.StringFlag{
+				Name:    "cucumber_pending_status",
+				EnvVars: []string{"PLUGIN_CUCUMBER_PENDING_STATUS"},
+			},
+			&cli.StringFlag{
+				Name:    "ctrf_output",
+				EnvVars: []string{"PLUGIN_CTRF_OUTPUT"},
+			},
+			&cli.IntFlag{
+				Name:    "max_output_bytes",
+				EnvVars: []string{"PLUGIN_MAX_OUTPUT_BYTES"},
+			},
+			&cli.BoolFlag{
+				Name:    "fail_on_parse_error",
+				EnvVars: []string{"PLUGIN_FAIL_ON_PARSE_ERROR"},
+			},
+			&cli.BoolFlag{
+				Name:    "recover_truncated_reports",
+				EnvVars: []string{"PLUGIN_RECOVER_TRUNCATED_REPORTS"},
+			},
+			&cli.StringFlag{
+				Name:    "retry_policy",
+				EnvVars: []string{"PLUGIN_RETRY_POLICY"},
+			},
+			&cli.IntFlag{
+				Name:    "workers",
+				EnvVars: []string{"PLUGIN_WORKERS"},
+			},
+			&cli.BoolFlag{
+				Name:    "debug",
+				EnvVars: []string{"PLUGIN_DEBUG"},
+			},
+			&cli.BoolFlag{
+				Name:    "from_stdin",
+				Aliases: []string{"from-stdin"},
+				EnvVars: []string{"PLUGIN_FROM_STDIN"},
+			},
+			&cli.StringFlag{
+				Name:    "quarantine_audit_json",
+				EnvVars: []string{"PLUGIN_QUARANTINE_AUDIT_JSON"},
+			},
+			&cli.StringFlag{
+				Name:    "quarantine_audit_markdown",
+				EnvVars: []string{"PLUGIN_QUARANTINE_AUDIT_MARKDOWN"},
+			},
+			&cli.BoolFlag{
+				Name:    "fail_on_stale_quarantine",
+				EnvVars: []string{"PLUGIN_FAIL_ON_STALE_QUARANTINE"},
+			},
+			&cli.StringFlag{
+				Name:    "pruned_quarantine_file",
+				EnvVars: []string{"PLUGIN_PRUNED_QUARANTINE_FILE"},
+			},
 		},
 	}
 	if err := app.Run(os.Args); err != nil {
@@ -46,6 +149,20 @@ func run(c *cli.Context) error {
 		GlobPaths:        c.String(globSetting),
 		QuarantineFile:   c.String(quarantineFileSetting),
 		FailOnQuarantine: c.Bool(quarantineSetting),
+		ReportFormat:     c.String(reportFormatSetting),
+		PendingStatus:    c.String(pendingStatusSetting),
+		CTRFOutput:       c.String(ctrfOutputSetting),
+		MaxOutputBytes:   c.Int(maxOutputBytesSetting),
+		FailOnParseError: c.Bool(parseErrorSetting),
+		RecoverTruncated: c.Bool(recoverSetting),
+		RetryPolicy:      c.String(retryPolicySetting),
+		Workers:          c.Int(workersSetting),
+		Debug:            c.Bool(debugSetting),
+		FromStdin:        c.Bool(fromStdinSetting),
+		AuditJSON:        c.String(auditJSONSetting),
+		AuditMarkdown:    c.String(auditMarkdownSetting),
+		FailOnStale:      c.Bool(failOnStaleSetting),
+		PrunedFile:       c.String(prunedFileSetting),
 	}
 	return p.Exec()
 }
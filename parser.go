@@ -2,10 +2,9 @@ package main
 
 import (
 	"errors"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 	"strconv"
@@ -13,7 +12,6 @@ import (
 	"github.com/harness-community/parse-test-reports/gojunit"
 	"github.com/mattn/go-zglob"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
 )
 
 func getPaths(globVal string) []string {
@@ -29,9 +27,10 @@ func getPaths(globVal string) []string {
 	return paths
 }
 
-// ParseTests parses XMLs and returns error if there are any failures
-func ParseTests(paths []string, log *logrus.Logger) (TestStats, error) {
+// ParseTests parses test reports and returns error if there are any failures
+func ParseTests(paths []string, opts gojunit.Options, policy gojunit.RetryPolicy, workers int, report *gojunit.CTRF, log *logrus.Logger) (TestStats, error) {
 	files := getFiles(paths, log)
+	defer closeArchives(log)
 	stats := TestStats{}
 
 	if len(files) == 0 {
@@ -39,56 +38,323 @@ func ParseTests(paths []string, log *logrus.Logger) (TestStats, error) {
 		return stats, errors.New("could not find any files matching the provided report path")
 	}
 
-	for _, file := range files {
-		suites, err := gojunit.IngestFile(file)
-		if err != nil {
-			log.WithError(err).WithField("file", file).Errorln("could not parse file")
-			continue
+	stats = parseFiles(files, opts, policy, workers, report, func(path gojunit.SuitePath, test *gojunit.Test, _ *TestStats) {
+		if isFailure(test) {
+			log.WithFields(logrus.Fields{
+				"suite":  path.String(),
+				"status": test.Result.Status,
+			}).Infoln("Test failed:", test.Classname+"."+test.Name)
 		}
-		fileStats := TestStats{}
-		for _, suite := range suites {
-			for _, test := range suite.Tests {
-				fileStats.TestCount++
-				switch test.Result.Status {
-				case "passed":
-					fileStats.PassCount++
-				case "failed":
-					fileStats.FailCount++
-				case "skipped":
-					fileStats.SkippedCount++
-				case "error":
-					fileStats.ErrorCount++
-				}
+	}, log)
+
+	if stats.FailCount > 0 || stats.ErrorCount > 0 {
+		return stats, errors.New("failed tests and errors found")
+	}
+	return stats, nil
+}
+
+// parseFiles parses the given report files, counts their tests and adds them to
+// the report, if one is collected, and then passes each test to fn along with
+// the stats of its file. The stats and tests of a file are only added to the
+// totals once the whole file was parsed, and files which cannot be parsed are
+// counted and logged instead.
+//
+// Files are parsed by the given number of workers at a time. Their tests are
+// then handled in the order of the files, so that the totals, the report and
+// the log do not depend on which file was parsed first.
+//
+// Unless the policy is gojunit.RetryNone, the executions of each test across all
+// files are merged according to the policy once every file was parsed, and only
+// the merged tests are counted and passed to fn, along with the totals.
+func parseFiles(files []string, opts gojunit.Options, policy gojunit.RetryPolicy, workers int, report *gojunit.CTRF, fn func(path gojunit.SuitePath, test *gojunit.Test, stats *TestStats), log *logrus.Logger) TestStats {
+	p := &fileParser{
+		opts:   opts,
+		report: report,
+		fn:     fn,
+		log:    log,
+	}
+	if policy != gojunit.RetryNone {
+		p.merger = gojunit.NewRetryMerger(policy)
+	}
+
+	if workers <= 1 {
+		for _, file := range files {
+			p.begin()
+			p.finish(parseFile(file, opts, p.test))
+		}
+	} else {
+		for result := range parseConcurrently(files, opts, workers) {
+			parsed := <-result
+			p.begin()
+			for i := range parsed.tests {
+				p.test(parsed.tests[i].path, &parsed.tests[i].test)
 			}
+			parsed.tests = nil
+			p.finish(parsed)
 		}
-		log.WithFields(logrus.Fields{
-			"file":    file,
-			"total":   fileStats.TestCount,
-			"passed":  fileStats.PassCount,
-			"failed":  fileStats.FailCount,
-			"skipped": fileStats.SkippedCount,
-			"errors":  fileStats.ErrorCount,
-		}).Infoln("File processed")
+	}
 
-		// Aggregate stats
-		stats.TestCount += fileStats.TestCount
-		stats.PassCount += fileStats.PassCount
-		stats.FailCount += fileStats.FailCount
-		stats.SkippedCount += fileStats.SkippedCount
-		stats.ErrorCount += fileStats.ErrorCount
+	logParseFailures(p.failures, log)
+
+	if p.merger != nil {
+		p.merger.Walk(func(path gojunit.SuitePath, test *gojunit.Test, executions int) {
+			p.stats.count(test.DerivedStatus())
+			if executions > 1 {
+				p.stats.RetriedCount++
+				log.WithFields(logrus.Fields{
+					"suite":      path.String(),
+					"executions": executions,
+					"status":     test.Result.Status,
+				}).Infoln("Test retried:", test.Classname+"."+test.Name)
+			}
+			logFlaky(path, test, log)
+			if report != nil {
+				report.AddTest(path, test)
+			}
+			fn(path, test, &p.stats)
+		})
 	}
 
-	if stats.FailCount > 0 || stats.ErrorCount > 0 {
-		return stats, errors.New("failed tests and errors found")
+	closeArchives(log)
+	return p.stats
+}
+
+// fileParser aggregates the tests of report files, one file at a time.
+type fileParser struct {
+	opts     gojunit.Options
+	report   *gojunit.CTRF
+	fn       func(path gojunit.SuitePath, test *gojunit.Test, stats *TestStats)
+	log      *logrus.Logger
+	merger   *gojunit.RetryMerger
+	stats    TestStats
+	failures []parseFailure
+
+	// fileStats, fileReport and fileTests are the stats, report and retained
+	// executions of the file which is being handled.
+	fileStats  TestStats
+	fileReport *gojunit.CTRF
+	fileTests  []execution
+}
+
+// begin starts handling the tests of a file.
+func (p *fileParser) begin() {
+	p.fileStats = TestStats{}
+	p.fileReport = newFileReport(p.report)
+	p.fileTests = nil
+}
+
+// test handles a test of the current file.
+func (p *fileParser) test(path gojunit.SuitePath, test *gojunit.Test) {
+	p.fileStats.count(test.DerivedStatus())
+	if p.merger != nil {
+		p.fileTests = append(p.fileTests, execution{path: path, test: *test})
+		return
 	}
-	return stats, nil
+	logFlaky(path, test, p.log)
+	if p.fileReport != nil {
+		p.fileReport.AddTest(path, test)
+	}
+	p.fn(path, test, &p.fileStats)
+}
+
+// finish ends the current file, and adds its stats and tests to the totals
+// unless it could not be parsed.
+func (p *fileParser) finish(parsed parsedFile) {
+	log := p.log
+	fields := logrus.Fields{
+		"file":   parsed.file,
+		"format": parsed.format,
+	}
+	if p.opts.Format != gojunit.FormatAuto {
+		log.WithFields(fields).Infoln("Using configured report format")
+	} else if parsed.format != gojunit.FormatAuto {
+		log.WithFields(fields).Infoln("Detected report format")
+	}
+	if parsed.incomplete {
+		log.WithField("file", parsed.file).Warnln("Report was truncated, recovered the tests which were complete")
+	}
+	log.WithFields(logrus.Fields{
+		"file":     parsed.file,
+		"duration": parsed.elapsed,
+		"tests":    p.fileStats.TestCount,
+	}).Debugln("File parsed")
+
+	if parsed.err != nil {
+		log.WithError(parsed.err).WithFields(parseErrorFields(parsed.file, parsed.err)).Errorln("could not parse file")
+		p.failures = append(p.failures, parseFailure{file: parsed.file, err: parsed.err})
+		p.stats.ParseErrorCount++
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"file":    parsed.file,
+		"total":   p.fileStats.TestCount,
+		"passed":  p.fileStats.PassCount,
+		"failed":  p.fileStats.FailCount,
+		"skipped": p.fileStats.SkippedCount,
+		"errors":  p.fileStats.ErrorCount,
+		"flaky":   p.fileStats.FlakyCount,
+	}).Infoln("File processed")
+
+	if p.merger != nil {
+		for i := range p.fileTests {
+			p.merger.Add(p.fileTests[i].path, &p.fileTests[i].test)
+		}
+		return
+	}
+	if p.report != nil {
+		p.report.Merge(p.fileReport)
+	}
+	p.stats.add(p.fileStats)
+}
+
+// execution is a test of a report file, along with the path of its suite, which
+// is retained until the file was parsed.
+type execution struct {
+	path gojunit.SuitePath
+	test gojunit.Test
+}
+
+// parsedFile is the outcome of parsing a report file.
+type parsedFile struct {
+	file       string
+	format     gojunit.Format
+	incomplete bool
+	elapsed    time.Duration
+	err        error
+
+	// tests are the tests of a file which was parsed by a worker, which are
+	// retained until the files before it were handled.
+	tests []execution
 }
 
-// getFiles returns unique file paths after expanding the input paths
+// parseFile parses the given report file according to the given options, and
+// passes each of its tests to fn as soon as it is parsed, so that large reports
+// are never held in memory in full. The file stdinPath is read from stdin.
+func parseFile(file string, opts gojunit.Options, fn func(path gojunit.SuitePath, test *gojunit.Test)) parsedFile {
+	parsed := parsedFile{file: file}
+	handler := gojunit.StreamHandler{
+		Test: func(path gojunit.SuitePath, test *gojunit.Test) error {
+			fn(path, test)
+			return nil
+		},
+		Suite: func(path gojunit.SuitePath, suite *gojunit.Suite) error {
+			parsed.incomplete = parsed.incomplete || suite.Properties[gojunit.IncompleteProperty] == "true"
+			return nil
+		},
+	}
+
+	start := time.Now()
+	if file == stdinPath {
+		opts.Filename = "stdin"
+		parsed.format, parsed.err = gojunit.StreamReader(os.Stdin, opts, handler)
+	} else {
+		parsed.format, parsed.err = gojunit.StreamFile(file, opts, handler)
+	}
+	parsed.elapsed = time.Since(start)
+	return parsed
+}
+
+// parseConcurrently parses the given files with the given number of workers,
+// and returns a channel which yields a channel of the outcome of each file, in
+// the order of the files. A file is only started once fewer than the given
+// number of files are being parsed or waiting to be received, which bounds the
+// tests that are retained.
+func parseConcurrently(files []string, opts gojunit.Options, workers int) <-chan chan parsedFile {
+	pending := make(chan chan parsedFile, workers-1)
+	go func() {
+		defer close(pending)
+		for _, file := range files {
+			result := make(chan parsedFile, 1)
+			pending <- result
+			go func(file string) {
+				var tests []execution
+				parsed := parseFile(file, opts, func(path gojunit.SuitePath, test *gojunit.Test) {
+					tests = append(tests, execution{path: path, test: *test})
+				})
+				parsed.tests = tests
+				result <- parsed
+			}(file)
+		}
+	}()
+	return pending
+}
+
+// isFailure returns whether the given test failed or had an error.
+func isFailure(test *gojunit.Test) bool {
+	return test.Result.Status == gojunit.StatusFailed || test.Result.Status == gojunit.StatusError
+}
+
+// logFlaky logs the given test if it passed when it was rerun after it failed.
+func logFlaky(path gojunit.SuitePath, test *gojunit.Test, log *logrus.Logger) {
+	if test.DerivedStatus() != gojunit.StatusFlaky {
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"suite":    path.String(),
+		"attempts": len(test.Attempts) + 1,
+	}).Infoln("Test flaky:", test.Classname+"."+test.Name)
+}
+
+// parseFailure is a report file which could not be parsed.
+type parseFailure struct {
+	file string
+	err  error
+}
+
+// parseErrorFields returns the log fields of a report file which could not be
+// parsed, including the position of the error and the offending text of the
+// report when they are known.
+func parseErrorFields(file string, err error) logrus.Fields {
+	fields := logrus.Fields{"file": file}
+	var syntaxErr *gojunit.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Line != 0 {
+		fields["line"] = syntaxErr.Line
+		fields["column"] = syntaxErr.Column
+		fields["snippet"] = syntaxErr.Snippet
+	}
+	return fields
+}
+
+// logParseFailures logs a report of every file which could not be parsed.
+func logParseFailures(failures []parseFailure, log *logrus.Logger) {
+	if len(failures) == 0 {
+		return
+	}
+	log.Errorf("%d report files could not be parsed:", len(failures))
+	for _, failure := range failures {
+		log.WithError(failure.err).WithFields(parseErrorFields(failure.file, failure.err)).Errorln("Unparsable report file")
+	}
+}
+
+// newFileReport returns a CTRF document for the tests of a single file, which is
+// merged into the given report once the file is parsed, or nil if no report is
+// collected. The tests of a file which cannot be parsed are left out of the
+// report, as they are left out of the stats.
+func newFileReport(report *gojunit.CTRF) *gojunit.CTRF {
+	if report == nil {
+		return nil
+	}
+	return gojunit.NewCTRF(report.GeneratedBy)
+}
+
+// stdinPath is the path of the report which is read from stdin, so that a test
+// runner can be piped into the plugin.
+const stdinPath = "-"
+
+// getFiles returns unique file paths after expanding the input paths, except for
+// stdinPath, which is returned as is. A path
+// such as "results.zip!**/TEST-*.xml" is expanded to the matching entries of the
+// matching archives, and an archive matched by a path without an entry pattern
+// is expanded to all of its entries.
 func getFiles(paths []string, log *logrus.Logger) []string {
 	var files []string
 	for _, p := range paths {
-		path, err := expandTilde(p)
+		if p == stdinPath {
+			files = append(files, p)
+			continue
+		}
+		archivePattern, entryPattern := gojunit.SplitArchivePath(p)
+		path, err := expandTilde(archivePattern)
 		if err != nil {
 			log.WithError(err).WithField("path", p).Errorln("error expanding path")
 			continue
@@ -98,12 +364,61 @@ func getFiles(paths []string, log *logrus.Logger) []string {
 			log.WithError(err).WithField("path", path).Errorln("error resolving path regex")
 			continue
 		}
-
-		files = append(files, matches...)
+		// Matches are sorted, as they are found concurrently.
+		sort.Strings(matches)
+
+		for _, match := range matches {
+			if gojunit.IsArchive(match) {
+				files = append(files, getArchiveFiles(match, entryPattern, log)...)
+			} else {
+				files = append(files, match)
+			}
+		}
 	}
 	return uniqueItems(files)
 }
 
+// closeArchives closes the archives which were opened to find or parse report
+// files, and removes their temporary files, including when no report file was
+// found in them.
+func closeArchives(log *logrus.Logger) {
+	if err := gojunit.CloseArchives(); err != nil {
+		log.WithError(err).Warnln("could not close archives")
+	}
+}
+
+// getArchiveFiles returns the paths of the entries of the given archive which
+// match the given pattern, or of all of its entries if the pattern is empty.
+func getArchiveFiles(archive, pattern string, log *logrus.Logger) []string {
+	entries, err := gojunit.ArchiveEntries(archive)
+	if err != nil {
+		log.WithError(err).WithField("archive", archive).Errorln("error reading archive")
+		return nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if pattern != "" {
+			matched, err := zglob.Match(pattern, entry)
+			if err != nil {
+				log.WithError(err).WithField("path", pattern).Errorln("error resolving archive entry regex")
+				return nil
+			}
+			if !matched {
+				continue
+			}
+		}
+		file := archive + gojunit.ArchiveSeparator + entry
+		log.WithFields(logrus.Fields{
+			"file":    file,
+			"archive": archive,
+			"entry":   entry,
+		}).Infoln("Found file in archive")
+		files = append(files, file)
+	}
+	return files
+}
+
 func uniqueItems(items []string) []string {
 	var result []string
 	set := make(map[string]bool)
@@ -134,56 +449,15 @@ func expandTilde(path string) (string, error) {
 	return filepath.Join(dir, path[1:]), nil
 }
 
-// LoadYAML reads a YAML file from either a URL or a local file
-func LoadYAML(source string) (map[string]interface{}, error) {
-	log := logrus.New()
-	log.Infoln("Loading YAML from source:", source)
-
-	var data []byte
-	var err error
-
-	if isURL(source) {
-		resp, err := http.Get(source)
-		if err != nil {
-			log.WithError(err).Errorln("Failed to fetch YAML from URL")
-			return nil, err
-		}
-		defer resp.Body.Close()
-
-		data, err = io.ReadAll(resp.Body)
-		if err != nil {
-			log.WithError(err).Errorln("Failed to read YAML data from URL")
-			return nil, err
-		}
-	} else {
-		data, err = os.ReadFile(source)
-		if err != nil {
-			log.WithError(err).Errorln("Failed to read local YAML file")
-			return nil, err
-		}
-	}
-
-	var result map[string]interface{}
-	err = yaml.Unmarshal(data, &result)
-	if err != nil {
-		log.WithError(err).Errorln("Failed to parse YAML")
-		return nil, err
-	}
-
-	log.Infoln("Successfully loaded and parsed YAML")
-	return result, nil
-}
-
 func isURL(source string) bool {
 	return strings.HasPrefix(source, "http")
 }
 
-// ParseTestsWithQuarantine parses XMLs, considers quarantined tests, and returns errors if any non-quarantined failures are found
-func ParseTestsWithQuarantine(paths []string, quarantineList map[string]interface{}, log *logrus.Logger) (TestStats, error) {
+// ParseTestsWithQuarantine parses test reports, considers quarantined tests, and returns errors if any non-quarantined failures are found
+func ParseTestsWithQuarantine(paths []string, quarantine *Quarantine, opts gojunit.Options, policy gojunit.RetryPolicy, workers int, report *gojunit.CTRF, log *logrus.Logger) (TestStats, error) {
 	files := getFiles(paths, log)
+	defer closeArchives(log)
 	stats := TestStats{}
-	nonQuarantinedFailures := 0
-	expiredTests := 0
 
 	if len(files) == 0 {
 		log.Errorln("could not find any files matching the provided report path")
@@ -191,140 +465,85 @@ func ParseTestsWithQuarantine(paths []string, quarantineList map[string]interfac
 	}
 
 	log.Infoln("Starting to parse tests with quarantine list")
+	now := time.Now()
 
-	for _, file := range files {
-		suites, err := gojunit.IngestFile(file)
-		if err != nil {
-			log.WithError(err).WithField("file", file).Errorln("could not parse file")
-			continue
+	stats = parseFiles(files, opts, policy, workers, report, func(path gojunit.SuitePath, test *gojunit.Test, fileStats *TestStats) {
+		failed := isFailure(test)
+		var entry *QuarantineEntry
+		if failed {
+			entry = quarantine.Lookup(path, test)
+		}
+		identified := entry
+		if identified == nil {
+			identified = quarantine.LookupIdentified(path, test)
 		}
-		fileStats := TestStats{}
-		for _, suite := range suites {
-			for _, test := range suite.Tests {
-				fileStats.TestCount++
-				testIdentifier := test.Classname + "." + test.Name
-				switch test.Result.Status {
-				case "passed":
-					fileStats.PassCount++
-				case "failed", "error":
-					if !isQuarantined(testIdentifier, quarantineList, log) {
-						log.Infoln("Not Quarantined test failed:", testIdentifier)
-						nonQuarantinedFailures++
-					} else if isExpired(testIdentifier, quarantineList, log) {
-						log.Infoln("Quarantined test expired:", testIdentifier)
-						expiredTests++
-					}
-
-					if test.Result.Status == "failed" {
-						fileStats.FailCount++
-					} else {
-						fileStats.ErrorCount++
-					}
-				case "skipped":
-					fileStats.SkippedCount++
-				}
+
+		// Tests which did not fail are recorded as well, so that quarantines
+		// of tests which pass again can be found, and so is every entry which
+		// identifies a test, so that an entry is not stale while a more
+		// specific entry quarantines its tests.
+		if identified != nil {
+			status := test.DerivedStatus()
+			for _, hit := range quarantine.Identifying(path, test) {
+				fileStats.quarantineHits = append(fileStats.quarantineHits, quarantineHit{
+					entry:     hit,
+					classname: test.Classname,
+					name:      test.Name,
+					status:    status,
+					used:      hit == identified,
+				})
 			}
 		}
-		log.WithFields(logrus.Fields{
-			"file":    file,
-			"total":   fileStats.TestCount,
-			"passed":  fileStats.PassCount,
-			"failed":  fileStats.FailCount,
-			"skipped": fileStats.SkippedCount,
-			"errors":  fileStats.ErrorCount,
-		}).Infoln("File processed")
+		if !failed {
+			return
+		}
 
-		stats.TestCount += fileStats.TestCount
-		stats.PassCount += fileStats.PassCount
-		stats.FailCount += fileStats.FailCount
-		stats.SkippedCount += fileStats.SkippedCount
-		stats.ErrorCount += fileStats.ErrorCount
-	}
+		testIdentifier := test.Classname + "." + test.Name
+
+		switch {
+		case entry == nil && identified != nil:
+			log.WithFields(logrus.Fields{
+				"suite":    path.String(),
+				"entry":    identified.ID(),
+				"line":     identified.Line,
+				"owner":    identified.Owner,
+				"ticket":   identified.Ticket,
+				"mismatch": identified.Mismatch(test),
+				"type":     test.Result.Type,
+				"message":  test.Result.Message,
+			}).Warnln("Quarantined test failed in a new way:", testIdentifier)
+			fileStats.nonQuarantined++
+		case entry == nil:
+			log.WithField("suite", path.String()).Infoln("Not Quarantined test failed:", testIdentifier)
+			fileStats.nonQuarantined++
+		case entry.Expired(now):
+			log.WithFields(logrus.Fields{
+				"suite":     path.String(),
+				"entry":     entry.ID(),
+				"line":      entry.Line,
+				"owner":     entry.Owner,
+				"ticket":    entry.Ticket,
+				"startDate": formatDate(entry.StartDate),
+				"endDate":   formatDate(entry.EndDate),
+			}).Infoln("Quarantined test expired:", testIdentifier)
+			fileStats.expired++
+		default:
+			log.WithFields(logrus.Fields{
+				"suite":  path.String(),
+				"entry":  entry.ID(),
+				"line":   entry.Line,
+				"owner":  entry.Owner,
+				"ticket": entry.Ticket,
+			}).Infoln("Quarantined test failed:", testIdentifier)
+		}
+	}, log)
 
-	if nonQuarantinedFailures > 0 || expiredTests > 0 {
+	if stats.nonQuarantined > 0 || stats.expired > 0 {
 		// Construct the error message by concatenating string values
-		errorMessage := "Non-quarantined failures: " + strconv.Itoa(nonQuarantinedFailures) +
-			", Expired tests: " + strconv.Itoa(expiredTests) + " found"
+		errorMessage := "Non-quarantined failures: " + strconv.Itoa(stats.nonQuarantined) +
+			", Expired tests: " + strconv.Itoa(stats.expired) + " found"
 		return stats, errors.New(errorMessage)
 	}
 
 	return stats, nil
 }
-
-func isQuarantined(testIdentifier string, quarantineList map[string]interface{}, log *logrus.Logger) bool {
-	log.Infoln("Checking if test is quarantined:", testIdentifier)
-	tests, ok := quarantineList["quarantine_tests"].([]interface{})
-	if !ok {
-		log.Warnln("Quarantine list invalid or missing 'quarantine_tests'")
-		return false
-	}
-	for _, test := range tests {
-		if testMap, ok := test.(map[interface{}]interface{}); ok {
-			if quarantinedIdentifier, found := matchTestIdentifier(testMap, testIdentifier, log); found {
-				log.Infoln("Test is quarantined:", quarantinedIdentifier)
-				return true
-			}
-		}
-	}
-	log.Infoln("Test is not quarantined:", testIdentifier)
-	return false
-}
-
-func isExpired(testIdentifier string, quarantineList map[string]interface{}, log *logrus.Logger) bool {
-	tests, ok := quarantineList["quarantine_tests"].([]interface{})
-	if !ok {
-		log.Warnln("Quarantine list invalid or missing 'quarantine_tests'")
-		return false
-	}
-	for _, test := range tests {
-		if testMap, ok := test.(map[interface{}]interface{}); ok {
-			if quarantinedIdentifier, found := matchTestIdentifier(testMap, testIdentifier, log); found {
-				startDate, startOk := testMap["start_date"].(string)
-				endDate, endOk := testMap["end_date"].(string)
-
-				if startOk && endOk {
-					currentDate := time.Now()
-
-					startTime, err := time.Parse("2006-01-02", startDate)
-					if err != nil {
-						log.WithError(err).Warnln("Failed to parse start_date")
-						continue
-					}
-
-					endTime, err := time.Parse("2006-01-02", endDate)
-					if err != nil {
-						log.WithError(err).Warnln("Failed to parse end_date")
-						continue
-					}
-
-					if currentDate.Before(startTime) || currentDate.After(endTime) {
-						log.WithFields(logrus.Fields{
-							"test":        quarantinedIdentifier,
-							"currentDate": currentDate,
-							"startDate":   startTime,
-							"endDate":     endTime,
-						}).Infoln("Current Date lies outside start_date and end_date.")
-						return true
-					}
-				}
-			}
-		}
-	}
-
-	log.Infoln("Test has no expiration set:", testIdentifier)
-	return false
-}
-
-func matchTestIdentifier(testMap map[interface{}]interface{}, identifier string, log *logrus.Logger) (string, bool) {
-	quarantinedClassname, classnameOk := testMap["classname"].(string)
-	quarantinedName, nameOk := testMap["name"].(string)
-
-	if classnameOk && nameOk {
-		quarantinedIdentifier := quarantinedClassname + "." + quarantinedName
-		if quarantinedIdentifier == identifier {
-			log.Infoln("Test", identifier, "is quarantined")
-			return quarantinedIdentifier, true
-		}
-	}
-	return "", false
-}
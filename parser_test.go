@@ -0,0 +1,211 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// discardLogger returns a logger which discards its output.
+func discardLogger() *logrus.Logger {
+	log := logrus.New()
+	log.Out = io.Discard
+	return log
+}
+
+// TestParseTestsFormats checks that the tests of the nested suites of NUnit,
+// xUnit.net, TestNG, Playwright and Mochawesome reports are all counted.
+func TestParseTestsFormats(t *testing.T) {
+	tests := map[string]TestStats{
+		"gojunit/testdata/nunit2.xml":       {TestCount: 4, PassCount: 1, FailCount: 1, SkippedCount: 1, ErrorCount: 1},
+		"gojunit/testdata/nunit3.xml":       {TestCount: 5, PassCount: 2, FailCount: 1, SkippedCount: 1, ErrorCount: 1},
+		"gojunit/testdata/xunit.xml":        {TestCount: 6, PassCount: 2, FailCount: 2, SkippedCount: 1, ErrorCount: 1},
+		"gojunit/testdata/testng.xml":       {TestCount: 4, PassCount: 1, FailCount: 1, SkippedCount: 1, ErrorCount: 1},
+		"gojunit/testdata/playwright.json":  {TestCount: 5, PassCount: 1, FailCount: 1, SkippedCount: 1, ErrorCount: 1, FlakyCount: 1},
+		"gojunit/testdata/mochawesome.json": {TestCount: 6, PassCount: 2, FailCount: 1, SkippedCount: 2, ErrorCount: 1},
+	}
+
+	for file, expected := range tests {
+		t.Run(file, func(t *testing.T) {
+			stats, _ := ParseTests([]string{file}, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+			assert.Equal(t, expected, stats)
+		})
+	}
+}
+
+// TestParseTestsNestedSuites checks that the tests of nested JUnit suites are
+// counted, and are passed on along with the path of their suite.
+func TestParseTestsNestedSuites(t *testing.T) {
+	files := []string{"testdata/nested.xml"}
+	expected := TestStats{TestCount: 4, PassCount: 2, FailCount: 1, ErrorCount: 1}
+
+	stats, err := ParseTests(files, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+	assert.EqualError(t, err, "failed tests and errors found")
+	assert.Equal(t, expected, stats)
+
+	var tests []string
+	report := gojunit.NewCTRF(appName)
+	stats = parseFiles(files, gojunit.Options{}, gojunit.RetryNone, 1, report, func(path gojunit.SuitePath, test *gojunit.Test, _ *TestStats) {
+		tests = append(tests, path.String()+": "+test.Classname+"."+test.Name)
+	}, discardLogger())
+	assert.Equal(t, expected, stats)
+	assert.Equal(t, []string{
+		"Outer: OuterTest.testTop",
+		"Outer/Inner: InnerTest.testPass",
+		"Outer/Inner: InnerTest.testFail",
+		"Outer/Inner/Deepest: DeepTest.testError",
+	}, tests)
+
+	var suites []string
+	for _, test := range report.Results.Tests {
+		suites = append(suites, test.Suite)
+	}
+	assert.Equal(t, []string{"Outer", "Outer > Inner", "Outer > Inner", "Outer > Inner > Deepest"}, suites)
+
+	// The failures of the nested suites are quarantined by their suites.
+	q, err := ParseQuarantine([]byte(`
+quarantine_tests:
+  - suite: Inner
+    name: testFail
+  - suite: Deepest
+    name: "*"
+`))
+	require.NoError(t, err)
+	stats, err = ParseTestsWithQuarantine(files, q, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.TestCount)
+	assert.Zero(t, stats.nonQuarantined)
+	require.Len(t, stats.quarantineHits, 2)
+	assert.Equal(t, &q.Entries[0], stats.quarantineHits[0].entry)
+	assert.Equal(t, &q.Entries[1], stats.quarantineHits[1].entry)
+}
+
+// TestParseTestsArchiveNoFiles checks that the temporary file of a compressed
+// archive is removed when none of its entries is a report file.
+func TestParseTestsArchiveNoFiles(t *testing.T) {
+	report, err := os.ReadFile("testdata/nested.xml")
+	require.NoError(t, err)
+	var archive bytes.Buffer
+	gw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "reports/TEST-nested.xml", Mode: 0o644, Size: int64(len(report))}))
+	_, err = tw.Write(report)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reports.tgz")
+	require.NoError(t, os.WriteFile(path, archive.Bytes(), 0o644))
+	temp := t.TempDir()
+	t.Setenv("TMPDIR", temp)
+
+	paths := []string{path + gojunit.ArchiveSeparator + "**/*.json"}
+	_, err = ParseTests(paths, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+	assert.EqualError(t, err, "could not find any files matching the provided report path")
+	entries, err := os.ReadDir(temp)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = ParseTestsWithQuarantine(paths, &Quarantine{}, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+	assert.EqualError(t, err, "could not find any files matching the provided report path")
+	entries, err = os.ReadDir(temp)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestWorkerCount(t *testing.T) {
+	workers, err := workerCount(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, workers)
+
+	workers, err = workerCount(3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, workers)
+
+	_, err = workerCount(-1)
+	assert.EqualError(t, err, "must not be negative")
+}
+
+// TestParseFilesConcurrently checks that files which are parsed by several
+// workers are handled in the order of the files, as they are by a single one,
+// and that files which cannot be parsed are counted but leave out their tests.
+func TestParseFilesConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := []string{
+		"gojunit/testdata/surefire.xml",
+		"gojunit/testdata/nunit3.xml",
+		"gojunit/testdata/jest.json",
+		"gojunit/testdata/tap14.tap",
+		"gojunit/testdata/go-test.json",
+	}
+	var files []string
+	for i := 0; i < 40; i++ {
+		data, err := os.ReadFile(fixtures[i%len(fixtures)])
+		require.NoError(t, err)
+		if i%13 == 7 {
+			data = []byte("<testsuite><testcase name=")
+		}
+		file := filepath.Join(dir, fmt.Sprintf("%02d%s", i, filepath.Ext(fixtures[i%len(fixtures)])))
+		require.NoError(t, os.WriteFile(file, data, 0o644))
+		files = append(files, file)
+	}
+
+	parse := func(workers int) (TestStats, []string) {
+		var tests []string
+		stats := parseFiles(files, gojunit.Options{}, gojunit.RetryNone, workers, nil, func(path gojunit.SuitePath, test *gojunit.Test, _ *TestStats) {
+			tests = append(tests, path.String()+"/"+test.Classname+"."+test.Name)
+		}, discardLogger())
+		return stats, tests
+	}
+
+	expectedStats, expectedTests := parse(1)
+	assert.Equal(t, 3, expectedStats.ParseErrorCount)
+	assert.NotZero(t, expectedStats.TestCount)
+
+	for _, workers := range []int{2, 8, 64} {
+		stats, tests := parse(workers)
+		assert.Equal(t, expectedStats, stats, "workers: %d", workers)
+		assert.Equal(t, expectedTests, tests, "workers: %d", workers)
+	}
+}
+
+// setStdin replaces stdin with the given file for the duration of the test.
+func setStdin(t *testing.T, file string) {
+	t.Helper()
+	stdin, err := os.Open(file)
+	require.NoError(t, err)
+	saved := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() {
+		os.Stdin = saved
+		stdin.Close()
+	})
+}
+
+// TestParseTestsStdin checks that a report read from stdin is detected like the
+// same report in a file, and is parsed along with the files matched by globs.
+func TestParseTestsStdin(t *testing.T) {
+	for _, fixture := range []string{"gojunit/testdata/go-test.json", "gojunit/testdata/tap14.tap", "gojunit/testdata/surefire.xml"} {
+		t.Run(fixture, func(t *testing.T) {
+			expected, _ := ParseTests([]string{fixture, "gojunit/testdata/nunit*.xml"}, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+			require.Zero(t, expected.ParseErrorCount)
+
+			setStdin(t, fixture)
+			stats, _ := ParseTests([]string{stdinPath, "gojunit/testdata/nunit*.xml"}, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+			assert.Equal(t, expected, stats)
+		})
+	}
+
+	assert.Equal(t, []string{"gojunit/testdata/jest.json", stdinPath}, getFiles([]string{"gojunit/testdata/jest.json", stdinPath, stdinPath}, discardLogger()))
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/harness-community/parse-test-reports/gojunit"
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,6 +14,20 @@ type Plugin struct {
 	GlobPaths        string
 	QuarantineFile   string
 	FailOnQuarantine bool
+	ReportFormat     string
+	PendingStatus    string
+	CTRFOutput       string
+	MaxOutputBytes   int
+	FailOnParseError bool
+	RecoverTruncated bool
+	RetryPolicy      string
+	Workers          int
+	Debug            bool
+	FromStdin        bool
+	AuditJSON        string
+	AuditMarkdown    string
+	FailOnStale      bool
+	PrunedFile       string
 }
 
 type TestStats struct {
@@ -19,23 +36,122 @@ type TestStats struct {
 	PassCount    int
 	SkippedCount int
 	ErrorCount   int
+
+	// FlakyCount is the number of tests which passed when they were rerun
+	// after they failed. They are not counted as passed.
+	FlakyCount int
+
+	// RetriedCount is the number of tests which were executed more than once,
+	// when the executions of tests are merged by a retry policy.
+	RetriedCount int
+
+	// ParseErrorCount is the number of report files which could not be parsed.
+	ParseErrorCount int
+
+	// nonQuarantined and expired are the number of failed tests which are not
+	// quarantined, and whose quarantine expired.
+	nonQuarantined int
+	expired        int
+
+	// quarantineHits are the executions of quarantined tests.
+	quarantineHits []quarantineHit
+}
+
+// count adds a test with the given status to the stats.
+func (s *TestStats) count(status gojunit.Status) {
+	s.TestCount++
+	switch status {
+	case gojunit.StatusPassed:
+		s.PassCount++
+	case gojunit.StatusFailed:
+		s.FailCount++
+	case gojunit.StatusSkipped:
+		s.SkippedCount++
+	case gojunit.StatusError:
+		s.ErrorCount++
+	case gojunit.StatusFlaky:
+		s.FlakyCount++
+	}
+}
+
+// add adds the test counts of the given stats to the stats.
+func (s *TestStats) add(other TestStats) {
+	s.TestCount += other.TestCount
+	s.PassCount += other.PassCount
+	s.FailCount += other.FailCount
+	s.SkippedCount += other.SkippedCount
+	s.ErrorCount += other.ErrorCount
+	s.FlakyCount += other.FlakyCount
+	s.RetriedCount += other.RetriedCount
+	s.nonQuarantined += other.nonQuarantined
+	s.expired += other.expired
+	s.quarantineHits = append(s.quarantineHits, other.quarantineHits...)
 }
 
 // Exec executes the plugin.
 func (p Plugin) Exec() error {
 	log := logrus.New()
 	log.Out = os.Stdout
+	if p.Debug {
+		log.SetLevel(logrus.DebugLevel)
+	}
 
-	if p.GlobPaths == "" {
+	if p.GlobPaths == "" && !p.FromStdin {
 		log.Errorf("%s plugin setting or %s environment variable is not set", globSetting, globEnv)
 		os.Exit(1)
 	}
 
+	format, formatErr := gojunit.ParseFormat(p.ReportFormat)
+	if formatErr != nil {
+		log.Errorf("Invalid %s plugin setting or %s environment variable: %s", reportFormatSetting, reportFormatEnv, formatErr)
+		os.Exit(1)
+	}
+
+	if p.MaxOutputBytes < 0 {
+		log.Errorf("Invalid %s plugin setting or %s environment variable: must not be negative", maxOutputBytesSetting, maxOutputBytesEnv)
+		os.Exit(1)
+	}
+
+	policy, policyErr := gojunit.ParseRetryPolicy(p.RetryPolicy)
+	if policyErr != nil {
+		log.Errorf("Invalid %s plugin setting or %s environment variable: %s", retryPolicySetting, retryPolicyEnv, policyErr)
+		os.Exit(1)
+	}
+
+	workers, workersErr := workerCount(p.Workers)
+	if workersErr != nil {
+		log.Errorf("Invalid %s plugin setting or %s environment variable: %s", workersSetting, workersEnv, workersErr)
+		os.Exit(1)
+	}
+
+	opts := gojunit.Options{
+		Format:         format,
+		MaxOutputBytes: p.MaxOutputBytes,
+		Recover:        p.RecoverTruncated,
+	}
+	switch status := gojunit.Status(p.PendingStatus); status {
+	case "", gojunit.StatusFailed, gojunit.StatusSkipped:
+		opts.PendingStatus = status
+	default:
+		log.Errorf("Invalid %s plugin setting or %s environment variable: must be %q or %q", pendingStatusSetting, pendingStatusEnv, gojunit.StatusFailed, gojunit.StatusSkipped)
+		os.Exit(1)
+	}
+
 	paths := getPaths(p.GlobPaths)
+	if p.FromStdin {
+		paths = append(paths, stdinPath)
+	}
 	log.Infof("Parsing test cases in globs: %s", paths)
 
 	var stats TestStats
 	var err error
+	var stale []staleEntry
+
+	// The merged report is only collected when it is written.
+	var report *gojunit.CTRF
+	if p.CTRFOutput != "" {
+		report = gojunit.NewCTRF(appName)
+	}
 
 	if p.FailOnQuarantine {
 		if p.QuarantineFile == "" {
@@ -43,22 +159,57 @@ func (p Plugin) Exec() error {
 			os.Exit(1)
 		}
 
-		quarantineList, loadErr := LoadYAML(p.QuarantineFile)
+		quarantine, loadErr := LoadQuarantine(p.QuarantineFile)
 		if loadErr != nil {
 			log.Errorf("Error loading quarantine file: %s", loadErr)
 			os.Exit(1)
 		}
+		log.WithFields(logrus.Fields{
+			"file":    p.QuarantineFile,
+			"entries": len(quarantine.Entries),
+		}).Infoln("Loaded quarantine file")
+
+		stats, err = ParseTestsWithQuarantine(paths, quarantine, opts, policy, workers, report, log)
+
+		audit := newQuarantineAudit(p.QuarantineFile, stats.quarantineHits, time.Now())
+		for _, output := range []struct {
+			path, setting string
+			markdown      bool
+		}{
+			{p.AuditJSON, auditJSONSetting, false},
+			{p.AuditMarkdown, auditMarkdownSetting, true},
+		} {
+			if output.path == "" {
+				continue
+			}
+			if writeErr := writeQuarantineAudit(audit, output.path, output.markdown, log); writeErr != nil {
+				log.Errorf("Error writing %s: %s", output.setting, writeErr)
+				os.Exit(1)
+			}
+		}
 
-		stats, err = ParseTestsWithQuarantine(paths, quarantineList, log)
+		var writeErr error
+		stale, writeErr = checkStaleEntries(quarantine, stats, err, p.PrunedFile, log)
+		if writeErr != nil {
+			log.Errorf("Error writing %s: %s", prunedFileSetting, writeErr)
+			os.Exit(1)
+		}
 	} else {
-		stats, err = ParseTests(paths, log)
+		stats, err = ParseTests(paths, opts, policy, workers, report, log)
 	}
 
 	// Always write output variables, even if there was an error
 	writeTestStats(stats, log)
 
-	log.Infof("Final test statistics: Total: %d, Passed: %d, Failed: %d, Skipped: %d, Errors: %d",
-		stats.TestCount, stats.PassCount, stats.FailCount, stats.SkippedCount, stats.ErrorCount)
+	if report != nil {
+		if writeErr := writeCTRF(report, p.CTRFOutput, log); writeErr != nil {
+			log.Errorf("Error writing %s: %s", ctrfOutputSetting, writeErr)
+			os.Exit(1)
+		}
+	}
+
+	log.Infof("Final test statistics: Total: %d, Passed: %d, Failed: %d, Skipped: %d, Errors: %d, Flaky: %d",
+		stats.TestCount, stats.PassCount, stats.FailCount, stats.SkippedCount, stats.ErrorCount, stats.FlakyCount)
 
 	// Handle the error after writing stats
 	if err != nil {
@@ -66,16 +217,43 @@ func (p Plugin) Exec() error {
 		os.Exit(1)
 	}
 
+	if p.FailOnParseError && stats.ParseErrorCount > 0 {
+		log.Errorf("%d report files could not be parsed and %s plugin setting or %s environment variable is set", stats.ParseErrorCount, parseErrorSetting, parseErrorEnv)
+		os.Exit(1)
+	}
+
+	if p.FailOnStale && len(stale) > 0 {
+		log.Errorf("%d quarantine entries are stale and %s plugin setting or %s environment variable is set", len(stale), failOnStaleSetting, failOnStaleEnv)
+		os.Exit(1)
+	}
+
 	return nil
 }
 
+// workerCount returns the number of workers which parse report files for the
+// given setting. Report files are parsed one at a time by default, as every
+// other worker holds the tests of a whole file in memory.
+func workerCount(workers int) (int, error) {
+	switch {
+	case workers < 0:
+		return 0, errors.New("must not be negative")
+	case workers == 0:
+		return 1, nil
+	default:
+		return workers, nil
+	}
+}
+
 func writeTestStats(stats TestStats, log *logrus.Logger) {
 	statsMap := map[string]int{
-		"TOTAL_TESTS":   stats.TestCount,
-		"FAILED_TESTS":  stats.FailCount,
-		"PASSED_TESTS":  stats.PassCount,
-		"SKIPPED_TESTS": stats.SkippedCount,
-		"ERROR_TESTS":   stats.ErrorCount,
+		"TOTAL_TESTS":      stats.TestCount,
+		"FAILED_TESTS":     stats.FailCount,
+		"PASSED_TESTS":     stats.PassCount,
+		"SKIPPED_TESTS":    stats.SkippedCount,
+		"ERROR_TESTS":      stats.ErrorCount,
+		"FLAKY_TESTS":      stats.FlakyCount,
+		"RETRIED_TESTS":    stats.RetriedCount,
+		"UNPARSABLE_FILES": stats.ParseErrorCount,
 	}
 
 	for key, value := range statsMap {
@@ -85,6 +263,25 @@ func writeTestStats(stats TestStats, log *logrus.Logger) {
 	}
 }
 
+// writeCTRF writes the merged CTRF report of all parsed tests to the given file.
+func writeCTRF(report *gojunit.CTRF, path string, log *logrus.Logger) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := report.Write(file); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"file":  path,
+		"tests": report.Results.Summary.Tests,
+	}).Infoln("CTRF report written")
+	return nil
+}
+
 func WriteEnvToFile(key, value string, log *logrus.Logger) error {
 	outputFile, err := os.OpenFile(os.Getenv("DRONE_OUTPUT"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
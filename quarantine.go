@@ -0,0 +1,675 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// quarantineVersion is the latest version of the schema of quarantine files. A
+// file without a version is read as version 1.
+const quarantineVersion = 1
+
+// dateLayout is the layout of the dates of quarantine entries.
+const dateLayout = "2006-01-02"
+
+// Quarantine is a quarantine file, which lists the tests whose failures do not
+// fail the build.
+type Quarantine struct {
+	Version int
+	Entries []QuarantineEntry
+
+	// doc is the parsed quarantine file, which is kept to prune its entries.
+	doc *yaml.Node
+}
+
+// QuarantineEntry quarantines the tests which match all of its patterns that
+// are set. Every entry has a name, which is "*" for an entry that quarantines
+// every test of its classes or suites.
+type QuarantineEntry struct {
+	Classname Pattern
+	Name      Pattern
+
+	// Suite matches any of the suites which contain a test.
+	Suite Pattern
+
+	// Type, Message and File constrain the failures which are quarantined,
+	// so that a quarantined test which fails in another way still fails the
+	// build. Type matches the type of the failure, Message is a regular
+	// expression which is searched for in the message and the description of
+	// the failure, and File matches the file of the test.
+	Type    Pattern
+	Message Pattern
+	File    Pattern
+
+	// StartDate and EndDate, if set, are the first and the last day on which
+	// the test is quarantined. The quarantine of a test which fails outside of
+	// them has expired.
+	StartDate *time.Time
+	EndDate   *time.Time
+
+	// Meta is a free-form note, such as the reason why the test fails.
+	Meta string
+
+	// Owner, Ticket and Reason record who is responsible for fixing the
+	// quarantined tests, the ticket which tracks it, and why they are
+	// quarantined.
+	Owner  string
+	Ticket string
+	Reason string
+
+	// Line is the line of the entry in the quarantine file.
+	Line int
+
+	// node is the entry in the parsed quarantine file.
+	node *yaml.Node
+}
+
+// ID describes the tests which the entry quarantines, as it is logged.
+func (e *QuarantineEntry) ID() string {
+	var parts []string
+	for _, field := range []struct {
+		key     string
+		pattern Pattern
+	}{
+		{"suite", e.Suite},
+		{"classname", e.Classname},
+		{"name", e.Name},
+		{"type", e.Type},
+		{"message", e.Message},
+		{"file", e.File},
+	} {
+		if field.pattern.Kind != PatternNone {
+			parts = append(parts, field.key+" "+field.pattern.String())
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Matches returns whether the entry quarantines the given test of the suite with
+// the given path, which it must both identify and whose failure it must allow.
+func (e *QuarantineEntry) Matches(path gojunit.SuitePath, test *gojunit.Test) bool {
+	return e.Identifies(path, test) && e.Mismatch(test) == ""
+}
+
+// Mismatch returns the key of the first constraint of the entry which the
+// failure of the given test does not meet, or an empty string if it meets all
+// of them.
+func (e *QuarantineEntry) Mismatch(test *gojunit.Test) string {
+	switch {
+	case e.Type.Kind != PatternNone && !e.Type.Match(test.Result.Type):
+		return "type"
+	case e.Message.Kind != PatternNone && !e.Message.Match(test.Result.Message) && !e.Message.Match(test.Result.Desc):
+		return "message"
+	case e.File.Kind != PatternNone && !e.File.Match(test.Filename):
+		return "file"
+	default:
+		return ""
+	}
+}
+
+// Identifies returns whether the entry identifies the given test of the suite
+// with the given path, regardless of its failure.
+func (e *QuarantineEntry) Identifies(path gojunit.SuitePath, test *gojunit.Test) bool {
+	if e.Classname.Kind != PatternNone && !e.Classname.Match(test.Classname) {
+		return false
+	}
+	if e.Name.Kind != PatternNone && !e.Name.Match(test.Name) {
+		return false
+	}
+	if e.Suite.Kind == PatternNone {
+		return true
+	}
+	for _, suite := range path {
+		if e.Suite.Match(suite) {
+			return true
+		}
+	}
+	return false
+}
+
+// specificity ranks how specifically the entry identifies the tests which it
+// quarantines, by its name, then its classname, then its suite and then the
+// number of constraints on their failures.
+func (e *QuarantineEntry) specificity() [4]int {
+	constraints := 0
+	for _, pattern := range []Pattern{e.Type, e.Message, e.File} {
+		if pattern.Kind != PatternNone {
+			constraints++
+		}
+	}
+	return [4]int{e.Name.Kind.rank(), e.Classname.Kind.rank(), e.Suite.Kind.rank(), constraints}
+}
+
+// PatternKind is how a Pattern matches text.
+type PatternKind int
+
+const (
+	// PatternNone is a pattern which is not set, and which matches anything.
+	PatternNone PatternKind = iota
+
+	// PatternExact matches text which equals the pattern.
+	PatternExact
+
+	// PatternGlob matches text which matches the pattern in full, where "*"
+	// matches any text and "?" matches any single character.
+	PatternGlob
+
+	// PatternRegex matches text which matches the regular expression in full.
+	PatternRegex
+
+	// PatternSearch matches text which contains a match of the regular
+	// expression.
+	PatternSearch
+)
+
+// rank returns how specific a pattern of the kind is. Exact patterns are more
+// specific than globs and regular expressions, which are more specific than no
+// pattern at all.
+func (k PatternKind) rank() int {
+	switch k {
+	case PatternExact:
+		return 2
+	case PatternGlob, PatternRegex, PatternSearch:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Pattern matches the classname, name or suite of a test. It is written in a
+// quarantine file as a string, for an exact match, or as a mapping with either
+// a "glob" or a "regex" key. The string "*" is the glob which matches anything.
+type Pattern struct {
+	Kind PatternKind
+	Text string
+
+	re *regexp.Regexp
+}
+
+// Match returns whether the pattern matches the given text.
+func (p *Pattern) Match(text string) bool {
+	switch p.Kind {
+	case PatternExact:
+		return text == p.Text
+	case PatternGlob, PatternRegex, PatternSearch:
+		return p.re.MatchString(text)
+	default:
+		return true
+	}
+}
+
+func (p Pattern) String() string {
+	switch p.Kind {
+	case PatternGlob:
+		return fmt.Sprintf("glob %q", p.Text)
+	case PatternRegex, PatternSearch:
+		return fmt.Sprintf("regex %q", p.Text)
+	default:
+		return fmt.Sprintf("%q", p.Text)
+	}
+}
+
+// globRegexp compiles the given glob into a regular expression which matches
+// text that matches the glob in full.
+func globRegexp(glob string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, char := range glob {
+		switch char {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// Expired returns whether the day of the given time lies outside of the dates
+// of the entry. The end date is inclusive.
+func (e *QuarantineEntry) Expired(now time.Time) bool {
+	day := quarantineDay(now)
+	if e.StartDate != nil && day.Before(*e.StartDate) {
+		return true
+	}
+	return e.EndDate != nil && day.After(*e.EndDate)
+}
+
+// quarantineDay returns the day of the given time in UTC, in which the dates of
+// quarantine entries are parsed, whatever the local time zone.
+func quarantineDay(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
+
+// formatDate formats the given date of a quarantine entry, which may be unset.
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(dateLayout)
+}
+
+// Lookup returns the entry which quarantines the given test of the suite with
+// the given path, or nil if it is not quarantined. When several entries match
+// the test, the most specific entry is returned, which is the entry with the
+// most specific name, then classname and then suite, where an exact pattern is
+// more specific than a glob or a regular expression, which is more specific
+// than none, and then the entry with the most constraints. Entries which are as
+// specific are resolved by the first of them.
+func (q *Quarantine) Lookup(path gojunit.SuitePath, test *gojunit.Test) *QuarantineEntry {
+	return q.lookup(func(entry *QuarantineEntry) bool {
+		return entry.Matches(path, test)
+	})
+}
+
+// LookupIdentified returns the entry which identifies the given test, such as
+// an entry which does not quarantine it as it failed in another way, or nil if
+// no entry identifies it. Entries are chosen as they are by Lookup.
+func (q *Quarantine) LookupIdentified(path gojunit.SuitePath, test *gojunit.Test) *QuarantineEntry {
+	return q.lookup(func(entry *QuarantineEntry) bool {
+		return entry.Identifies(path, test)
+	})
+}
+
+// Identifying returns every entry which identifies the given test of the suite
+// with the given path, in the order of the quarantine file.
+func (q *Quarantine) Identifying(path gojunit.SuitePath, test *gojunit.Test) []*QuarantineEntry {
+	var entries []*QuarantineEntry
+	for i := range q.Entries {
+		if q.Entries[i].Identifies(path, test) {
+			entries = append(entries, &q.Entries[i])
+		}
+	}
+	return entries
+}
+
+// lookup returns the most specific entry which matches.
+func (q *Quarantine) lookup(matches func(entry *QuarantineEntry) bool) *QuarantineEntry {
+	var found *QuarantineEntry
+	for i := range q.Entries {
+		entry := &q.Entries[i]
+		if !matches(entry) {
+			continue
+		}
+		if found == nil || moreSpecific(entry.specificity(), found.specificity()) {
+			found = entry
+		}
+	}
+	return found
+}
+
+// moreSpecific returns whether the specificity a ranks above b.
+func moreSpecific(a, b [4]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
+// QuarantineProblem is a problem of a quarantine file, at the given line.
+type QuarantineProblem struct {
+	Line    int
+	Message string
+}
+
+func (p QuarantineProblem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// QuarantineError is returned for a quarantine file which is not valid, along
+// with every problem which was found in it.
+type QuarantineError struct {
+	Problems []QuarantineProblem
+}
+
+func (e *QuarantineError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.String()
+	}
+	return fmt.Sprintf("invalid quarantine file: %s", strings.Join(problems, "; "))
+}
+
+// LoadQuarantine reads a quarantine file from either a URL or a local file, and
+// parses it with ParseQuarantine.
+func LoadQuarantine(source string) (*Quarantine, error) {
+	data, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQuarantine(data)
+}
+
+// readSource reads the given URL or local file.
+func readSource(source string) ([]byte, error) {
+	if !isURL(source) {
+		return os.ReadFile(source)
+	}
+
+	resp, err := http.Get(source) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ParseQuarantine parses and validates a quarantine file. Unknown keys, missing
+// or malformed values, an end date before the start date and duplicate entries
+// are all rejected with a *QuarantineError, which lists every problem that was
+// found along with its line, rather than only the first.
+func ParseQuarantine(data []byte) (*Quarantine, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	q := &Quarantine{Version: quarantineVersion, doc: &doc}
+	v := &quarantineValidator{}
+	if len(doc.Content) != 0 {
+		v.file(q, doc.Content[0])
+	}
+	if len(v.problems) != 0 {
+		return nil, &QuarantineError{Problems: v.problems}
+	}
+	return q, nil
+}
+
+// quarantineValidator collects the problems of a quarantine file.
+type quarantineValidator struct {
+	problems []QuarantineProblem
+}
+
+func (v *quarantineValidator) problem(node *yaml.Node, format string, args ...interface{}) {
+	v.problems = append(v.problems, QuarantineProblem{Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+// file decodes the root of a quarantine file.
+func (v *quarantineValidator) file(q *Quarantine, root *yaml.Node) {
+	if root.Kind != yaml.MappingNode {
+		v.problem(root, "quarantine file must be a mapping")
+		return
+	}
+
+	v.mapping(root, "quarantine file", func(key string, value *yaml.Node) bool {
+		switch key {
+		case "version":
+			var version int
+			if value.Kind != yaml.ScalarNode || value.Decode(&version) != nil {
+				v.problem(value, "version must be a number")
+			} else if version < 1 || version > quarantineVersion {
+				v.problem(value, "unsupported version %d, the latest version is %d", version, quarantineVersion)
+			} else {
+				q.Version = version
+			}
+		case "quarantine_tests":
+			v.entries(q, value)
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+// entries decodes the list of quarantine entries.
+func (v *quarantineValidator) entries(q *Quarantine, list *yaml.Node) {
+	if list.Tag == "!!null" {
+		return
+	}
+	if list.Kind != yaml.SequenceNode {
+		v.problem(list, "quarantine_tests must be a list")
+		return
+	}
+
+	// Entries are duplicates if they have the same patterns, and therefore
+	// the same ID.
+	seen := make(map[string]int)
+	for _, node := range list.Content {
+		entry, ok := v.entry(node)
+		if !ok {
+			continue
+		}
+		if line, ok := seen[entry.ID()]; ok {
+			v.problem(node, "duplicate entry for %s, which is first quarantined at line %d", entry.ID(), line)
+			continue
+		}
+		seen[entry.ID()] = entry.Line
+		q.Entries = append(q.Entries, entry)
+	}
+}
+
+// entry decodes a quarantine entry, and returns whether it is valid.
+func (v *quarantineValidator) entry(node *yaml.Node) (QuarantineEntry, bool) {
+	entry := QuarantineEntry{Line: node.Line, node: node}
+	if node.Kind != yaml.MappingNode {
+		v.problem(node, "quarantine entry must be a mapping")
+		return entry, false
+	}
+
+	problems := len(v.problems)
+	var start, end *yaml.Node
+	targeted, named := false, false
+	v.mapping(node, "quarantine entry", func(key string, value *yaml.Node) bool {
+		switch key {
+		case "classname":
+			entry.Classname, targeted = v.pattern(key, value), true
+		case "name":
+			entry.Name, named = v.pattern(key, value), true
+		case "suite":
+			entry.Suite, targeted = v.pattern(key, value), true
+		case "type":
+			entry.Type = v.pattern(key, value)
+		case "message":
+			entry.Message = v.search(key, value)
+		case "file":
+			entry.File = v.pattern(key, value)
+		case "start_date":
+			entry.StartDate, start = v.date(key, value), value
+		case "end_date":
+			entry.EndDate, end = v.date(key, value), value
+		case "meta":
+			entry.Meta = v.string(key, value)
+		case "owner":
+			entry.Owner = v.string(key, value)
+		case "ticket":
+			entry.Ticket = v.string(key, value)
+		case "reason":
+			entry.Reason = v.string(key, value)
+		default:
+			return false
+		}
+		return true
+	})
+
+	// Patterns which are not valid were reported already.
+	decoded := len(v.problems) == problems
+	if !targeted || decoded && entry.Classname.Kind == PatternNone && entry.Suite.Kind == PatternNone {
+		v.problem(node, "quarantine entry is missing its classname or suite")
+	}
+	// An entry quarantines every test of its classes or suites only if it
+	// says so, rather than when its name is left out by mistake.
+	if !named || decoded && entry.Name.Kind == PatternNone {
+		v.problem(node, `quarantine entry is missing its name, which is "*" to quarantine every test`)
+	}
+	if entry.StartDate != nil && entry.EndDate != nil && entry.EndDate.Before(*entry.StartDate) {
+		v.problem(end, "end_date %s is before start_date %s at line %d", end.Value, start.Value, start.Line)
+	}
+	return entry, len(v.problems) == problems
+}
+
+// mapping calls fn with every key of the given mapping, and reports the keys
+// which fn does not know, as well as duplicate keys.
+func (v *quarantineValidator) mapping(node *yaml.Node, what string, fn func(key string, value *yaml.Node) bool) {
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if seen[key.Value] {
+			v.problem(key, "duplicate key %q in %s", key.Value, what)
+			continue
+		}
+		seen[key.Value] = true
+		if !fn(key.Value, value) {
+			v.problem(key, "unknown key %q in %s", key.Value, what)
+		}
+	}
+}
+
+// string decodes a string value, which may be empty.
+func (v *quarantineValidator) string(key string, node *yaml.Node) string {
+	switch {
+	case node.Tag == "!!null":
+		return ""
+	case node.Kind != yaml.ScalarNode:
+		v.problem(node, "%s must be a string", key)
+		return ""
+	default:
+		return node.Value
+	}
+}
+
+// pattern decodes a pattern, which is either a string or a mapping with a glob
+// or a regex.
+func (v *quarantineValidator) pattern(key string, node *yaml.Node) Pattern {
+	if node.Kind != yaml.MappingNode {
+		text := v.string(key, node)
+		switch text {
+		case "":
+			return Pattern{}
+		case "*":
+			return Pattern{Kind: PatternGlob, Text: text, re: globRegexp(text)}
+		default:
+			return Pattern{Kind: PatternExact, Text: text}
+		}
+	}
+
+	var pattern Pattern
+	v.mapping(node, key, func(form string, value *yaml.Node) bool {
+		switch form {
+		case "glob", "regex":
+		default:
+			return false
+		}
+		if pattern.Kind != PatternNone {
+			v.problem(value, "%s must have only one of glob and regex", key)
+			return true
+		}
+		text := v.string(key+" "+form, value)
+		if text == "" {
+			v.problem(value, "%s %s must not be empty", key, form)
+			return true
+		}
+
+		if form == "glob" {
+			pattern = Pattern{Kind: PatternGlob, Text: text, re: globRegexp(text)}
+			return true
+		}
+		if _, err := regexp.Compile(text); err != nil {
+			v.problem(value, "%s regex %q is not valid: %s", key, text, err)
+			return true
+		}
+		pattern = Pattern{Kind: PatternRegex, Text: text, re: regexp.MustCompile("^(?:" + text + ")$")}
+		return true
+	})
+	if len(node.Content) == 0 {
+		v.problem(node, "%s must have a glob or a regex", key)
+	}
+	return pattern
+}
+
+// search decodes a regular expression, which is searched for in text.
+func (v *quarantineValidator) search(key string, node *yaml.Node) Pattern {
+	text := v.string(key, node)
+	if text == "" {
+		return Pattern{}
+	}
+	re, err := regexp.Compile(text)
+	if err != nil {
+		v.problem(node, "%s %q is not a valid regular expression: %s", key, text, err)
+		return Pattern{}
+	}
+	return Pattern{Kind: PatternSearch, Text: text, re: re}
+}
+
+// date decodes a date value, which may be empty. Dates are read from their
+// text, so that quoted and unquoted dates are the same.
+func (v *quarantineValidator) date(key string, node *yaml.Node) *time.Time {
+	value := v.string(key, node)
+	if value == "" {
+		return nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		v.problem(node, "%s %q is not a date of the form YYYY-MM-DD", key, value)
+		return nil
+	}
+	return &date
+}
+
+// validateQuarantine is the action of the "quarantine validate" command, which
+// validates the quarantine file given as its argument, or else by the
+// quarantine_file setting, and logs every problem of it.
+func validateQuarantine(c *cli.Context) error {
+	log := logrus.New()
+	log.Out = os.Stdout
+
+	source := c.Args().First()
+	if source == "" {
+		source = c.String(quarantineFileSetting)
+	}
+	if source == "" {
+		log.Errorf("Usage: %s quarantine validate <file>, or set the %s plugin setting or %s environment variable", appName, quarantineFileSetting, quarantineFileEnv)
+		os.Exit(1)
+	}
+
+	if !checkQuarantine(source, log) {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// checkQuarantine loads the given quarantine file, logs every problem of it, and
+// returns whether it is valid.
+func checkQuarantine(source string, log *logrus.Logger) bool {
+	q, err := LoadQuarantine(source)
+	var invalid *QuarantineError
+	if errors.As(err, &invalid) {
+		for _, problem := range invalid.Problems {
+			log.WithFields(logrus.Fields{
+				"file": source,
+				"line": problem.Line,
+			}).Errorln(problem.Message)
+		}
+		log.Errorf("%d problems found in quarantine file %s", len(invalid.Problems), source)
+		return false
+	}
+	if err != nil {
+		log.Errorf("Error loading quarantine file: %s", err)
+		return false
+	}
+
+	log.WithFields(logrus.Fields{
+		"file":    source,
+		"version": q.Version,
+		"entries": len(q.Entries),
+	}).Infoln("Quarantine file is valid")
+	return true
+}
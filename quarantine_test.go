@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// date returns the given date of the form YYYY-MM-DD.
+func date(t *testing.T, value string) *time.Time {
+	t.Helper()
+	parsed, err := time.Parse(dateLayout, value)
+	require.NoError(t, err)
+	return &parsed
+}
+
+func TestParseQuarantine(t *testing.T) {
+	q, err := ParseQuarantine([]byte(`
+quarantine_tests:
+  - classname: com.example.FooTest
+    name: "testC with data set #1"
+    start_date: 2024-01-01
+    end_date: "2024-12-31"
+    meta: Fails on the new runners
+  - suite: Checkout
+    name: testPay
+    start_date:
+    end_date:
+`))
+	require.NoError(t, err)
+	assert.Equal(t, quarantineVersion, q.Version)
+	require.Len(t, q.Entries, 2)
+
+	entry := q.Entries[0]
+	assert.Equal(t, Pattern{Kind: PatternExact, Text: "com.example.FooTest"}, entry.Classname)
+	assert.Equal(t, Pattern{Kind: PatternExact, Text: "testC with data set #1"}, entry.Name)
+	assert.Equal(t, date(t, "2024-01-01"), entry.StartDate)
+	assert.Equal(t, date(t, "2024-12-31"), entry.EndDate)
+	assert.Equal(t, "Fails on the new runners", entry.Meta)
+	assert.Equal(t, 3, entry.Line)
+
+	entry = q.Entries[1]
+	assert.Equal(t, Pattern{Kind: PatternExact, Text: "Checkout"}, entry.Suite)
+	assert.Nil(t, entry.StartDate)
+	assert.Nil(t, entry.EndDate)
+	assert.Equal(t, 8, entry.Line)
+}
+
+func TestParseQuarantineProblems(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		problems []QuarantineProblem
+	}{
+		"unknown key of the file": {
+			input: "version: 1\nquarantine_test: []\n",
+			problems: []QuarantineProblem{
+				{Line: 2, Message: `unknown key "quarantine_test" in quarantine file`},
+			},
+		},
+		"unsupported version": {
+			input: "version: 2\nquarantine_tests: []\n",
+			problems: []QuarantineProblem{
+				{Line: 1, Message: "unsupported version 2, the latest version is 1"},
+			},
+		},
+		"not a mapping": {
+			input: "- classname: A\n",
+			problems: []QuarantineProblem{
+				{Line: 1, Message: "quarantine file must be a mapping"},
+			},
+		},
+		"entries not a list": {
+			input: "quarantine_tests:\n  classname: A\n",
+			problems: []QuarantineProblem{
+				{Line: 2, Message: "quarantine_tests must be a list"},
+			},
+		},
+		"unknown key of an entry": {
+			input: "quarantine_tests:\n  - classname: A\n    name: a\n    test_name: a\n",
+			problems: []QuarantineProblem{
+				{Line: 4, Message: `unknown key "test_name" in quarantine entry`},
+			},
+		},
+		"duplicate key": {
+			input: "quarantine_tests:\n  - classname: A\n    name: a\n    name: b\n",
+			problems: []QuarantineProblem{
+				{Line: 4, Message: `duplicate key "name" in quarantine entry`},
+			},
+		},
+		"duplicate entry": {
+			input: "quarantine_tests:\n  - classname: A\n    name: a\n  - classname: B\n    name: b\n  - name: a\n    classname: A\n",
+			problems: []QuarantineProblem{
+				{Line: 6, Message: `duplicate entry for classname "A", name "a", which is first quarantined at line 2`},
+			},
+		},
+		"end date before start date": {
+			input: "quarantine_tests:\n  - classname: A\n    name: a\n    start_date: 2024-02-01\n    end_date: 2024-01-31\n",
+			problems: []QuarantineProblem{
+				{Line: 5, Message: "end_date 2024-01-31 is before start_date 2024-02-01 at line 4"},
+			},
+		},
+		"malformed date": {
+			input: "quarantine_tests:\n  - classname: A\n    name: a\n    end_date: 31/01/2024\n",
+			problems: []QuarantineProblem{
+				{Line: 4, Message: `end_date "31/01/2024" is not a date of the form YYYY-MM-DD`},
+			},
+		},
+		"missing classname and suite": {
+			input: "quarantine_tests:\n  - name: a\n",
+			problems: []QuarantineProblem{
+				{Line: 2, Message: "quarantine entry is missing its classname or suite"},
+			},
+		},
+		"every problem": {
+			input: "quarantine_tests:\n  - name: a\n  - classname: B\n    name: b\n    owner: [x]\n  - classname: C\n    name: c\n    end_date: soon\n",
+			problems: []QuarantineProblem{
+				{Line: 2, Message: "quarantine entry is missing its classname or suite"},
+				{Line: 5, Message: "owner must be a string"},
+				{Line: 8, Message: `end_date "soon" is not a date of the form YYYY-MM-DD`},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseQuarantine([]byte(test.input))
+			var invalid *QuarantineError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, test.problems, invalid.Problems)
+		})
+	}
+}
+
+func TestParseQuarantineError(t *testing.T) {
+	_, err := ParseQuarantine([]byte("quarantine_tests:\n  - name: a\n  - classname: B\n    name: b\n    owner: [x]\n"))
+	assert.EqualError(t, err, "invalid quarantine file: line 2: quarantine entry is missing its classname or suite; line 5: owner must be a string")
+}
+
+func TestQuarantineEntryExpired(t *testing.T) {
+	entry := QuarantineEntry{StartDate: date(t, "2024-03-01"), EndDate: date(t, "2024-03-31")}
+
+	tests := map[string]bool{
+		"2024-02-29T23:59:59Z": true,
+		"2024-03-01T00:00:00Z": false,
+		"2024-03-31T00:00:00Z": false,
+		"2024-03-31T23:59:59Z": false,
+		"2024-04-01T00:00:00Z": true,
+	}
+	for now, expired := range tests {
+		at, err := time.Parse(time.RFC3339, now)
+		require.NoError(t, err)
+		assert.Equal(t, expired, entry.Expired(at), now)
+	}
+
+	assert.False(t, (&QuarantineEntry{}).Expired(time.Now()))
+}
+
+func TestQuarantineListFile(t *testing.T) {
+	q, err := LoadQuarantine("quarantinelist.yaml")
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range q.Entries {
+		if entry.Classname.Text == "SampleTest" {
+			names = append(names, entry.Name.Text)
+		}
+	}
+	assert.Equal(t, []string{`testB with data set "bool"`, "testC with data set #1", "testC with data set #2"}, names)
+}
+
+func TestCheckQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte("version: 1\nquarantine_tests:\n  - classname: A\n    name: a\n"), 0o644))
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("quarantine_tests:\n  - classname: A\n    name: a\n    color: red\n"), 0o644))
+
+	var out bytes.Buffer
+	log := logrus.New()
+	log.Out = &out
+	log.Formatter = &logrus.TextFormatter{DisableTimestamp: true}
+
+	assert.True(t, checkQuarantine(valid, log))
+	assert.Contains(t, out.String(), `msg="Quarantine file is valid" entries=1`)
+
+	out.Reset()
+	assert.False(t, checkQuarantine(invalid, log))
+	assert.Contains(t, out.String(), `level=error msg="unknown key \"color\" in quarantine entry" file=`+invalid+" line=4")
+	assert.Contains(t, out.String(), "1 problems found in quarantine file "+invalid)
+
+	out.Reset()
+	assert.False(t, checkQuarantine(filepath.Join(dir, "missing.yaml"), log))
+	assert.Contains(t, out.String(), "Error loading quarantine file")
+}
+
+func TestParseQuarantineName(t *testing.T) {
+	tests := map[string]string{
+		"missing":      "quarantine_tests:\n  - classname: A\n",
+		"empty":        "quarantine_tests:\n  - classname: A\n    name: \"\"\n",
+		"null":         "quarantine_tests:\n  - classname: A\n    name:\n",
+		"and no class": "quarantine_tests:\n  - name: \"\"\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseQuarantine([]byte(input))
+			var invalid *QuarantineError
+			require.ErrorAs(t, err, &invalid)
+			assert.Contains(t, invalid.Problems, QuarantineProblem{Line: 2, Message: `quarantine entry is missing its name, which is "*" to quarantine every test`})
+		})
+	}
+
+	q, err := ParseQuarantine([]byte("quarantine_tests:\n  - classname: A\n    name: \"*\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, PatternGlob, q.Entries[0].Name.Kind)
+	assert.True(t, q.Entries[0].Identifies(nil, &gojunit.Test{Classname: "A", Name: "anything"}))
+	assert.False(t, q.Entries[0].Identifies(nil, &gojunit.Test{Classname: "B", Name: "anything"}))
+
+	// "*" is the same as the glob which matches anything.
+	_, err = ParseQuarantine([]byte("quarantine_tests:\n  - classname: A\n    name: \"*\"\n  - classname: A\n    name: {glob: \"*\"}\n"))
+	assert.ErrorContains(t, err, `line 4: duplicate entry for classname "A", name glob "*"`)
+}
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		matches bool
+	}{
+		{`"a.b"`, "a.b", true},
+		{`"a.b"`, "axb", false},
+		{`"a.b"`, "a.b.c", false},
+		{`{glob: "test_*:?"}`, "test_params:2", true},
+		{`{glob: "test_*:?"}`, "test_params:12", false},
+		{`{glob: "a.*"}`, "a.b.c", true},
+		{`{glob: "a.*"}`, "axb", false},
+		{`{glob: "*[1]"}`, "test[1]", true},
+		{`{glob: "*[1]"}`, "test1", false},
+		{`{regex: 'com\.example\..*IT'}`, "com.example.FooIT", true},
+		{`{regex: 'com\.example\..*IT'}`, "com.example.FooITCase", false},
+		{`{regex: 'a|b'}`, "a", true},
+		{`{regex: 'a|b'}`, "ab", false},
+	}
+
+	for _, test := range tests {
+		q, err := ParseQuarantine([]byte("quarantine_tests:\n  - classname: " + test.pattern + "\n    name: \"*\"\n"))
+		require.NoError(t, err, test.pattern)
+		pattern := q.Entries[0].Classname
+		assert.Equal(t, test.matches, pattern.Match(test.text), "%s matches %q", test.pattern, test.text)
+	}
+}
+
+func TestParsePatternProblems(t *testing.T) {
+	tests := map[string]string{
+		`{glob: ""}`:              `line 2: classname glob must not be empty`,
+		`{regex: "("}`:            "line 2: classname regex \"(\" is not valid: error parsing regexp: missing closing ): `(`",
+		`{glob: "a", regex: "a"}`: `line 2: classname must have only one of glob and regex`,
+		`{prefix: "a"}`:           `line 2: unknown key "prefix" in classname`,
+		`{}`:                      `line 2: classname must have a glob or a regex`,
+	}
+	for pattern, expected := range tests {
+		_, err := ParseQuarantine([]byte("quarantine_tests:\n  - classname: " + pattern + "\n    name: \"*\"\n"))
+		assert.EqualError(t, err, "invalid quarantine file: "+expected, pattern)
+	}
+}
+
+func TestQuarantineLookup(t *testing.T) {
+	q, err := ParseQuarantine([]byte(`
+quarantine_tests:
+  - classname: {regex: 'pkg\..*'}
+    name: "*"
+  - classname: {glob: "pkg.*"}
+    name: test
+  - classname: pkg.A
+    name: "*"
+  - classname: pkg.A
+    name: {glob: "test*"}
+  - classname: pkg.A
+    name: test
+  - suite: Nightly
+    name: test
+  - suite: {glob: "*"}
+    name: test
+  - classname: pkg.C
+    name: "*"
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		title     string
+		path      gojunit.SuitePath
+		classname string
+		name      string
+		line      int
+	}{
+		{"exact name and classname", nil, "pkg.A", "test", 11},
+		{"glob classname over exact suite", gojunit.SuitePath{"Nightly"}, "pkg.B", "test", 5},
+		{"first of as specific globs", nil, "pkg.A", "testMore", 7},
+		{"exact classname over glob classname", nil, "pkg.A", "other", 7},
+		{"regex classname", nil, "pkg.B", "other", 3},
+		{"exact name over glob name", nil, "pkg.B", "test", 5},
+		{"exact suite of the path", gojunit.SuitePath{"All", "Nightly"}, "other.D", "test", 13},
+		{"glob suite", gojunit.SuitePath{"All"}, "other.D", "test", 15},
+		{"exact classname over regex classname", nil, "pkg.C", "other", 17},
+		{"no entry", nil, "other.D", "other", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			entry := q.Lookup(test.path, &gojunit.Test{Classname: test.classname, Name: test.name})
+			if test.line == 0 {
+				assert.Nil(t, entry)
+				return
+			}
+			require.NotNil(t, entry)
+			assert.Equal(t, test.line, entry.Line, entry.ID())
+		})
+	}
+}
+
+func TestQuarantineEntryConstraints(t *testing.T) {
+	q, err := ParseQuarantine([]byte(`
+quarantine_tests:
+  - classname: A
+    name: type
+    type: {glob: "*TimeoutException"}
+  - classname: A
+    name: message
+    message: 'staging-\w+\.example'
+  - classname: A
+    name: file
+    file: {glob: "src/legacy/*"}
+  - classname: A
+    name: all
+    type: java.lang.AssertionError
+    message: expected
+    file: src/a_test.go
+`))
+	require.NoError(t, err)
+
+	failure := func(name, kind, message, desc, file string) *gojunit.Test {
+		return &gojunit.Test{
+			Classname: "A",
+			Name:      name,
+			Filename:  file,
+			Result:    gojunit.Result{Status: gojunit.StatusFailed, Type: kind, Message: message, Desc: desc},
+		}
+	}
+
+	tests := []struct {
+		title    string
+		test     *gojunit.Test
+		mismatch string
+	}{
+		{"type matches", failure("type", "java.net.SocketTimeoutException", "", "", ""), ""},
+		{"type differs", failure("type", "java.lang.AssertionError", "", "", ""), "type"},
+		{"type is missing", failure("type", "", "", "", ""), "type"},
+		{"message found in the message", failure("message", "", "could not reach staging-db.example.com", "", ""), ""},
+		{"message found in the description", failure("message", "", "timed out", "dial tcp: staging-db.example.com: i/o timeout", ""), ""},
+		{"message not found", failure("message", "", "timed out", "dial tcp: prod-db.example.com", ""), "message"},
+		{"file matches", failure("file", "", "", "", "src/legacy/a_test.go"), ""},
+		{"file differs", failure("file", "", "", "", "src/a_test.go"), "file"},
+		{"all match", failure("all", "java.lang.AssertionError", "expected 1", "", "src/a_test.go"), ""},
+		{"first constraint which differs", failure("all", "java.lang.AssertionError", "got 1", "", "src/b_test.go"), "message"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			identified := q.LookupIdentified(nil, test.test)
+			require.NotNil(t, identified)
+			assert.Equal(t, test.test.Name, identified.Name.Text)
+			assert.Equal(t, test.mismatch, identified.Mismatch(test.test))
+
+			entry := q.Lookup(nil, test.test)
+			if test.mismatch == "" {
+				assert.Same(t, identified, entry)
+			} else {
+				assert.Nil(t, entry)
+			}
+		})
+	}
+}
+
+func TestQuarantineLookupConstraints(t *testing.T) {
+	q, err := ParseQuarantine([]byte(`
+quarantine_tests:
+  - classname: A
+    name: test
+  - classname: A
+    name: test
+    type: TimeoutException
+  - classname: A
+    name: test
+    type: TimeoutException
+    message: staging
+`))
+	require.NoError(t, err)
+
+	test := &gojunit.Test{Classname: "A", Name: "test", Result: gojunit.Result{Type: "TimeoutException", Message: "staging is down"}}
+	assert.Equal(t, 8, q.Lookup(nil, test).Line)
+	test.Result.Message = "prod is down"
+	assert.Equal(t, 5, q.Lookup(nil, test).Line)
+	test.Result.Type = "AssertionError"
+	assert.Equal(t, 3, q.Lookup(nil, test).Line)
+}
+
+// TestParseTestsWithQuarantineConstraints checks that a quarantined test which
+// fails in a way that its entry does not expect is not quarantined. The result
+// of testMultiple is its error, which is more severe than its failure.
+func TestParseTestsWithQuarantineConstraints(t *testing.T) {
+	report := []string{"gojunit/testdata/surefire-rerun.xml"}
+	parse := func(quarantine string) (TestStats, error) {
+		q, err := ParseQuarantine([]byte(quarantine))
+		require.NoError(t, err)
+		return ParseTestsWithQuarantine(report, q, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+	}
+
+	stats, err := parse(`
+quarantine_tests:
+  - classname: com.example.FooTest
+    name: testBroken
+    type: java.lang.AssertionError
+    message: 'FooTest\.java:30'
+  - classname: com.example.FooTest
+    name: testMultiple
+    type: org.opentest4j.MultipleFailuresError
+`)
+	assert.EqualError(t, err, "Non-quarantined failures: 1, Expired tests: 0 found")
+	assert.Equal(t, 1, stats.nonQuarantined)
+
+	stats, err = parse(`
+quarantine_tests:
+  - classname: com.example.FooTest
+    name: testBroken
+    type: java.lang.AssertionError
+    message: 'FooTest\.java:30'
+  - classname: com.example.FooTest
+    name: testMultiple
+    type: java.lang.IllegalStateException
+`)
+	assert.NoError(t, err)
+	assert.Zero(t, stats.nonQuarantined)
+}
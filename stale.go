@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// staleEntry is a quarantine entry which no longer quarantines any failure,
+// either because every test it matched passed, or because it matched no test.
+type staleEntry struct {
+	entry  *QuarantineEntry
+	passed int
+}
+
+// unused returns whether the entry matched no test at all.
+func (s staleEntry) unused() bool {
+	return s.passed == 0
+}
+
+// findStaleEntries returns the entries of the quarantine which only matched
+// tests that passed, and those which matched no test, in the order of the
+// quarantine file. Entries which matched a test that failed, was flaky or was
+// skipped are not stale.
+func findStaleEntries(quarantine *Quarantine, hits []quarantineHit) []staleEntry {
+	passed := make(map[*QuarantineEntry]int)
+	active := make(map[*QuarantineEntry]bool)
+	for _, hit := range hits {
+		if hit.status == gojunit.StatusPassed {
+			passed[hit.entry]++
+		} else {
+			active[hit.entry] = true
+		}
+	}
+
+	var stale []staleEntry
+	for i := range quarantine.Entries {
+		entry := &quarantine.Entries[i]
+		if !active[entry] {
+			stale = append(stale, staleEntry{entry: entry, passed: passed[entry]})
+		}
+	}
+	return stale
+}
+
+// checkStaleEntries finds and logs the stale entries of the quarantine, and
+// writes the quarantine file without them to the given file, if any. Entries
+// are only stale when every report was parsed and had tests, and the tests were
+// handled without an error, as an entry would otherwise be pruned because its
+// tests were not read.
+func checkStaleEntries(quarantine *Quarantine, stats TestStats, parseErr error, pruned string, log *logrus.Logger) ([]staleEntry, error) {
+	switch {
+	case parseErr != nil:
+		log.Warnf("Not checking for stale quarantine entries, as parsing the tests failed: %s", parseErr)
+		return nil, nil
+	case stats.ParseErrorCount > 0:
+		log.Warnf("Not checking for stale quarantine entries, as %d report files could not be parsed", stats.ParseErrorCount)
+		return nil, nil
+	case stats.TestCount == 0:
+		log.Warnln("Not checking for stale quarantine entries, as the reports have no tests")
+		return nil, nil
+	}
+
+	stale := findStaleEntries(quarantine, stats.quarantineHits)
+	logStaleEntries(stale, log)
+	if pruned == "" {
+		return stale, nil
+	}
+	return stale, writePrunedQuarantine(quarantine, stale, pruned, log)
+}
+
+// logStaleEntries logs the given stale entries.
+func logStaleEntries(stale []staleEntry, log *logrus.Logger) {
+	for _, s := range stale {
+		fields := logrus.Fields{
+			"entry":  s.entry.ID(),
+			"line":   s.entry.Line,
+			"owner":  s.entry.Owner,
+			"ticket": s.entry.Ticket,
+		}
+		if s.unused() {
+			log.WithFields(fields).Warnln("Quarantine entry matched no test")
+		} else {
+			log.WithFields(fields).WithField("passed", s.passed).Warnln("Quarantine entry only matched passing tests")
+		}
+	}
+}
+
+// prune returns the quarantine file without the given entries. The comments and
+// the order of the rest of the file are kept.
+func (q *Quarantine) prune(entries map[*QuarantineEntry]bool) ([]byte, error) {
+	pruned := make(map[*yaml.Node]bool, len(entries))
+	for entry := range entries {
+		pruned[entry.node] = true
+	}
+
+	// The file is copied down to its list of entries, so that the quarantine
+	// is left as it was parsed.
+	doc := *q.doc
+	if len(doc.Content) != 0 {
+		root := *doc.Content[0]
+		root.Content = append([]*yaml.Node(nil), root.Content...)
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value != "quarantine_tests" || root.Content[i+1].Kind != yaml.SequenceNode {
+				continue
+			}
+			list := *root.Content[i+1]
+			list.Content = nil
+			for _, node := range root.Content[i+1].Content {
+				if !pruned[node] {
+					list.Content = append(list.Content, node)
+				}
+			}
+			root.Content[i+1] = &list
+		}
+		doc.Content = []*yaml.Node{&root}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePrunedQuarantine writes the quarantine file without its stale entries to
+// the given file.
+func writePrunedQuarantine(quarantine *Quarantine, stale []staleEntry, path string, log *logrus.Logger) error {
+	entries := make(map[*QuarantineEntry]bool, len(stale))
+	for _, s := range stale {
+		entries[s.entry] = true
+	}
+
+	data, err := quarantine.prune(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"file":    path,
+		"entries": len(quarantine.Entries) - len(stale),
+		"pruned":  len(stale),
+	}).Infoln("Pruned quarantine file written")
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harness-community/parse-test-reports/gojunit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staleQuarantine is the quarantine file of the stale tests.
+const staleQuarantine = `# Quarantined tests of the checkout service.
+quarantine_tests:
+  # Fixed in PAY-1234.
+  - classname: com.example.CheckoutTest
+    name: testPayment
+    ticket: PAY-1234
+  - classname: com.example.CartTest # flaky on CI
+    name: testAdd
+  # Deleted along with the search service.
+  - classname: com.example.SearchTest
+    name: testQuery
+  - classname: com.example.LoginTest
+    name: "*"
+`
+
+func TestFindStaleEntries(t *testing.T) {
+	q, err := ParseQuarantine([]byte(staleQuarantine))
+	require.NoError(t, err)
+	payment, cart, search, login := &q.Entries[0], &q.Entries[1], &q.Entries[2], &q.Entries[3]
+
+	tests := []struct {
+		name     string
+		hits     []quarantineHit
+		expected []staleEntry
+	}{
+		{
+			name:     "no hits",
+			expected: []staleEntry{{payment, 0}, {cart, 0}, {search, 0}, {login, 0}},
+		},
+		{
+			name: "passed only",
+			hits: []quarantineHit{
+				{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusPassed, true},
+				{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusPassed, true},
+				{cart, "com.example.CartTest", "testAdd", gojunit.StatusPassed, true},
+			},
+			expected: []staleEntry{{payment, 2}, {cart, 1}, {search, 0}, {login, 0}},
+		},
+		{
+			name: "active",
+			hits: []quarantineHit{
+				{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusPassed, true},
+				{payment, "com.example.CheckoutTest", "testPayment", gojunit.StatusFailed, true},
+				{cart, "com.example.CartTest", "testAdd", gojunit.StatusFlaky, true},
+				{search, "com.example.SearchTest", "testQuery", gojunit.StatusSkipped, true},
+				{login, "com.example.LoginTest", "testLogin", gojunit.StatusError, true},
+			},
+		},
+		{
+			name: "unused hits",
+			hits: []quarantineHit{
+				{login, "com.example.LoginTest", "testLogin", gojunit.StatusPassed, true},
+				{login, "com.example.LoginTest", "testLogout", gojunit.StatusFailed, false},
+			},
+			expected: []staleEntry{{payment, 0}, {cart, 0}, {search, 0}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, findStaleEntries(q, test.hits))
+		})
+	}
+}
+
+// TestFindStaleEntriesOverlapping checks that an entry is not stale while a
+// more specific entry quarantines the failures of its tests.
+func TestFindStaleEntriesOverlapping(t *testing.T) {
+	q, err := ParseQuarantine([]byte(`
+quarantine_tests:
+  - classname: com.example.FooTest
+    name: {regex: "^test(Broken|Stable)$"}
+  - classname: com.example.FooTest
+    name: testBroken
+    type: java.lang.AssertionError
+  - classname: com.example.FooTest
+    name: testStable
+  - classname: com.example.FooTest
+    name: testRemoved
+  - classname: com.example.FooTest
+    name: testMultiple
+`))
+	require.NoError(t, err)
+	stats, parseErr := ParseTestsWithQuarantine([]string{"gojunit/testdata/surefire-rerun.xml"}, q, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+	require.NoError(t, parseErr)
+
+	stale, err := checkStaleEntries(q, stats, parseErr, "", discardLogger())
+	require.NoError(t, err)
+	assert.Equal(t, []staleEntry{{&q.Entries[2], 1}, {&q.Entries[3], 0}}, stale)
+}
+
+// TestCheckStaleEntriesUnparsed checks that no entry is stale, and that the
+// quarantine file is not pruned, unless every report was parsed and had tests,
+// and the tests were handled without an error.
+func TestCheckStaleEntriesUnparsed(t *testing.T) {
+	q, err := ParseQuarantine([]byte(staleQuarantine))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	reports := map[string]string{
+		"passed.xml": `<testsuite name="s"><testcase classname="A" name="a"/></testsuite>`,
+		"failed.xml": `<testsuite name="s"><testcase classname="A" name="a"><failure message="broken"/></testcase></testsuite>`,
+		"broken.xml": `<testsuite name="s"><testcase name=`,
+		"blank.xml":  ``,
+	}
+	for name, report := range reports {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(report), 0o644))
+	}
+
+	tests := []struct {
+		name    string
+		reports []string
+		stale   int
+	}{
+		{"no files", []string{"missing/*.xml"}, 0},
+		{"parse error", []string{"passed.xml", "broken.xml"}, 0},
+		{"no tests", []string{"blank.xml"}, 0},
+		{"failed", []string{"failed.xml"}, 0},
+		{"parsed", []string{"passed.xml"}, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var paths []string
+			for _, report := range test.reports {
+				paths = append(paths, filepath.Join(dir, report))
+			}
+			stats, parseErr := ParseTestsWithQuarantine(paths, q, gojunit.Options{}, gojunit.RetryNone, 1, nil, discardLogger())
+
+			pruned := filepath.Join(t.TempDir(), "pruned.yaml")
+			stale, err := checkStaleEntries(q, stats, parseErr, pruned, discardLogger())
+			require.NoError(t, err)
+			assert.Len(t, stale, test.stale)
+			if test.stale == 0 {
+				assert.NoFileExists(t, pruned)
+			} else {
+				assert.FileExists(t, pruned)
+			}
+		})
+	}
+}
+
+func TestPruneQuarantine(t *testing.T) {
+	q, err := ParseQuarantine([]byte(staleQuarantine))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		stale    []int
+		expected string
+	}{
+		{
+			name:     "nothing",
+			expected: staleQuarantine,
+		},
+		{
+			name:  "first and last",
+			stale: []int{0, 3},
+			expected: `# Quarantined tests of the checkout service.
+quarantine_tests:
+  - classname: com.example.CartTest # flaky on CI
+    name: testAdd
+  # Deleted along with the search service.
+  - classname: com.example.SearchTest
+    name: testQuery
+`,
+		},
+		{
+			name:  "middle",
+			stale: []int{1, 2},
+			expected: `# Quarantined tests of the checkout service.
+quarantine_tests:
+  # Fixed in PAY-1234.
+  - classname: com.example.CheckoutTest
+    name: testPayment
+    ticket: PAY-1234
+  - classname: com.example.LoginTest
+    name: "*"
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stale []staleEntry
+			for _, i := range test.stale {
+				stale = append(stale, staleEntry{entry: &q.Entries[i]})
+			}
+			path := filepath.Join(t.TempDir(), "pruned.yaml")
+			require.NoError(t, writePrunedQuarantine(q, stale, path, discardLogger()))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(data))
+
+			pruned, err := ParseQuarantine(data)
+			require.NoError(t, err)
+			assert.Len(t, pruned.Entries, len(q.Entries)-len(test.stale))
+		})
+	}
+}
+
+// TestPruneQuarantineFlow checks that only the stale entries are pruned when
+// several entries share a line.
+func TestPruneQuarantineFlow(t *testing.T) {
+	q, err := ParseQuarantine([]byte("quarantine_tests: [{classname: A, name: a}, {classname: B, name: b}, {classname: C, name: c}]\n"))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "pruned.yaml")
+	require.NoError(t, writePrunedQuarantine(q, []staleEntry{{entry: &q.Entries[1]}}, path, discardLogger()))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "quarantine_tests: [{classname: A, name: a}, {classname: C, name: c}]\n", string(data))
+
+	// The quarantine is left as it was parsed.
+	require.NoError(t, writePrunedQuarantine(q, []staleEntry{{entry: &q.Entries[0]}}, path, discardLogger()))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "quarantine_tests: [{classname: B, name: b}, {classname: C, name: c}]\n", string(data))
+}